@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// remindcal agenda FILE [--from DATE] [--days N] [--format text|json|tsv]
+// Prints the per day listing of the events window without starting curses
+func agendaCommand(args []string) int {
+	fs := flag.NewFlagSet("agenda", flag.ContinueOnError)
+	from := fs.String("from", "", "first day YYYY-MM-DD ( default today )")
+	days := fs.Int("days", 7, "number of days")
+	format := fs.String("format", "text", "output format text, json or tsv")
+	empty := fs.Bool("empty", false, "also list days without events")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remindcal agenda FILE [--from DATE] [--days N] [--format text|json|tsv] [--empty]\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil { return 2 }
+	if len(positional) != 1 { fs.Usage(); return 2 }
+	filename := positional[0]
+
+	start, err := dateOrToday(*from)
+	if err != nil { fmt.Fprintf(os.Stderr, "remindcal: %s\n", err); return 2 }
+	if *days < 1 { fmt.Fprintf(os.Stderr, "remindcal: --days must be at least 1\n"); return 2 }
+
+	events, err := getEventsRange(filename, start, *days)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+		if events == nil { return 1 }
+	}
+
+	switch *format {
+	case "text":
+		writeAgendaText(os.Stdout, start, *days, events, *empty)
+	case "tsv":
+		writeAgendaTSV(os.Stdout, start, *days, events)
+	case "json":
+		if err := writeAgendaJSON(os.Stdout, start, *days, events, *empty); err != nil {
+			fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "remindcal: unknown format %q\n", *format)
+		return 2
+	}
+	return 0
+}
+
+// flag stops at the first positional argument, this allows flags after FILE as well
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil { return nil, err }
+		args = fs.Args()
+		if len(args) == 0 { return positional, nil }
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func dateOrToday(str string) (Date, error) {
+	if str == "" {
+		t := time.Now()
+		return NewDate(t.Year(), int(t.Month()), t.Day())
+	}
+	return parseISODate(str)
+}
+
+// Loads the events of days days starting at start
+// As with getEvents the events are returned together with a *RemindError
+// if remind reported problems but still produced output
+func getEventsRange(filename string, start Date, days int) (map[string][]Event, error) {
+	end := start
+	for i := 1; i < days; i++ { end.AddDay() }
+	nrOfMonth := (end.Year-start.Year)*12 + end.Month - start.Month + 1
+
+	eventsArr, err := getEvents(filename, start.Year, start.Month, nrOfMonth)
+	if eventsArr == nil { return nil, err }
+	events := map[string][]Event{}
+	for _, e := range eventsArr {
+		if e.IsDaySpecial() { continue }
+		addEvent(e, events)
+	}
+	sortEvents(events)
+	return events, err
+}
+
+func dateLabel(d Date) string {
+	return fmt.Sprintf("%s %d, %d", time.Month(d.Month).String(), d.Day, d.Year)
+}
+
+func writeAgendaText(w io.Writer, d Date, days int, events map[string][]Event, empty bool) {
+	first := true
+	for i := 0; i < days; i++ {
+		dayEvents := events[d.NumericString()]
+		if len(dayEvents) > 0 || empty {
+			if !first { fmt.Fprintln(w) }
+			first = false
+			fmt.Fprintln(w, dateLabel(d))
+			for _, e := range dayEvents {
+				fmt.Fprintf(w, "  %s\n", e.Message)
+			}
+		}
+		d.AddDay()
+	}
+}
+
+// one line per event: date time duration tags file line message
+// time and duration are separate columns so the message is used without time prefix
+func writeAgendaTSV(w io.Writer, d Date, days int, events map[string][]Event) {
+	clean := strings.NewReplacer("\t", " ", "\n", " ")
+	for i := 0; i < days; i++ {
+		for _, e := range events[d.NumericString()] {
+			clock, duration := "", ""
+			if e.IsTimed() { clock = formatMinutes(e.Time) }
+			if e.Duration > 0 { duration = fmt.Sprint(e.Duration) }
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", remDate(d), clock, duration,
+				strings.Join(e.Tags, ","), e.Filename, e.Lineno, clean.Replace(e.Title()))
+		}
+		d.AddDay()
+	}
+}
+
+type agendaDay struct {
+	Date string `json:"date"`
+	Events []agendaEvent `json:"events"`
+}
+type agendaEvent struct {
+	Message string `json:"message"`
+	Time string `json:"time,omitempty"`
+	Duration int `json:"duration,omitempty"`
+	Tags []string `json:"tags"`
+	Priority int `json:"priority"`
+	Filename string `json:"filename"`
+	Lineno int `json:"lineno"`
+}
+
+func writeAgendaJSON(w io.Writer, d Date, days int, events map[string][]Event, empty bool) error {
+	agenda := []agendaDay{}
+	for i := 0; i < days; i++ {
+		day := agendaDay{remDate(d), []agendaEvent{}}
+		for _, e := range events[d.NumericString()] {
+			ae := agendaEvent{Message: e.Title(), Tags: e.Tags, Priority: e.Priority, Filename: e.Filename, Lineno: e.Lineno}
+			if e.IsTimed() { ae.Time = formatMinutes(e.Time) }
+			if e.Duration > 0 { ae.Duration = e.Duration }
+			day.Events = append(day.Events, ae)
+		}
+		if len(day.Events) > 0 || empty { agenda = append(agenda, day) }
+		d.AddDay()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(agenda)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAgendaUnicode(t *testing.T) {
+	reminders := readUnicodeFixture(t)
+	events := unicodeFixtureEvents(t)
+	start, _ := NewDate(2026, 1, 1)
+
+	var text bytes.Buffer
+	writeAgendaText(&text, start, 365, events, false)
+	if !utf8.Valid(text.Bytes()) { t.Error("text agenda is not valid UTF-8") }
+	for _, r := range reminders {
+		if !strings.Contains(text.String(), r.message) { t.Errorf("text agenda is missing %q", r.message) }
+	}
+
+	var tsv bytes.Buffer
+	writeAgendaTSV(&tsv, start, 365, events)
+	lines := strings.Split(strings.TrimSuffix(tsv.String(), "\n"), "\n")
+	if len(lines) != len(reminders) { t.Fatalf("tsv agenda has %d lines, want %d", len(lines), len(reminders)) }
+	messages := map[string]bool{}
+	for _, line := range lines {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 { t.Errorf("tsv line %q has %d fields", line, len(fields)); continue }
+		messages[fields[6]] = true
+	}
+	for _, r := range reminders {
+		if !messages[r.message] { t.Errorf("tsv agenda is missing %q", r.message) }
+	}
+
+	var out bytes.Buffer
+	if err := writeAgendaJSON(&out, start, 365, events, false); err != nil { t.Fatal(err) }
+	agenda := []agendaDay{}
+	if err := json.Unmarshal(out.Bytes(), &agenda); err != nil { t.Fatal(err) }
+	messages = map[string]bool{}
+	for _, day := range agenda {
+		for _, e := range day.Events { messages[e.Message] = true }
+	}
+	for _, r := range reminders {
+		if !messages[r.message] { t.Errorf("json agenda is missing %q", r.message) }
+	}
+}
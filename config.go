@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings read from $XDG_CONFIG_HOME/remindcal/config.toml, every setting
+// can be overridden on the command line with --set section.key=value
+//
+//	path = "~/.reminders"       # used if no FILE is given
+//	week_start = "monday"       # sunday, monday or saturday
+//	editor = "nvim +{line} {file}"
+//	time_format = "24h"         # or "12h"
+//	search_years = 1            # years searched before and after the selected one
+//
+//	[remind]
+//	command = "remind"
+//	args = ["-b1"]
+//
+//	[panes]
+//	today = false               # today window, toggled with R
+//	week = false                # start in the week view
+//	debug = false
+//
+//	[colors]                    # "fg" or "fg on bg", names or 0-255
+//	highlight = "red"
+//	today = "yellow"
+//
+//	[tag_colors]                # like [colors]
+//	work = "blue"
+//
+//	[keys]
+//	quit = "q"
+type Config struct {
+	Path string
+	WeekStart time.Weekday
+	Editor string // command template, {file} and {line} are replaced, empty uses $EDITOR
+	Clock12h bool
+	SearchYears int
+
+	RemindCommand string
+	RemindArgs []string
+
+	TodayPane bool
+	WeekPane bool
+	Debug bool
+
+	Colors map[string]ColorSpec
+	TagColors map[string]ColorSpec
+	Keys map[string][]string // command name to keys
+}
+
+type ColorSpec struct {
+	Fg int
+	Bg int
+}
+
+// Color names of the config file and the pairs they set
+var colorSettings = []struct {
+	name string
+	pair int
+	color ColorSpec
+}{
+	{"highlight", 1, ColorSpec{COLOR_RED, -1}},    // active window, labels, selection
+	{"events", 2, ColorSpec{COLOR_CYAN, -1}},      // days with events
+	{"today", 3, ColorSpec{COLOR_YELLOW, -1}},
+	{"status", 5, ColorSpec{COLOR_WHITE, COLOR_BLUE}},
+	{"week_block", 6, ColorSpec{COLOR_BLACK, COLOR_CYAN}},
+	{"week_selected", 7, ColorSpec{COLOR_WHITE, COLOR_RED}},
+}
+
+var colorNames = map[string]int{
+	"default": -1,
+	"black": COLOR_BLACK,
+	"red": COLOR_RED,
+	"green": COLOR_GREEN,
+	"yellow": COLOR_YELLOW,
+	"blue": COLOR_BLUE,
+	"magenta": COLOR_MAGENTA,
+	"cyan": COLOR_CYAN,
+	"white": COLOR_WHITE,
+}
+
+func DefaultConfig() Config {
+	cfg := Config{
+		WeekStart: time.Monday,
+		SearchYears: 1,
+		RemindCommand: "remind",
+		RemindArgs: []string{},
+		Colors: map[string]ColorSpec{},
+		TagColors: map[string]ColorSpec{},
+		Keys: map[string][]string{},
+	}
+	for _, setting := range colorSettings { cfg.Colors[setting.name] = setting.color }
+	return cfg
+}
+
+// $XDG_CONFIG_HOME/remindcal/config.toml, ~/.config if XDG_CONFIG_HOME is not set
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil { return "" }
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "remindcal", "config.toml")
+}
+
+// Reads the config file at path into cfg, a missing file is not an error
+// unless required is set ( path given with --config )
+func loadConfigFile(cfg *Config, path string, required bool) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !required { return nil }
+	if err != nil { return err }
+	defer f.Close()
+
+	values, err := parseConfig(bufio.NewScanner(f))
+	if err != nil { return fmt.Errorf("%s: %w", path, err) }
+	keys := []string{}
+	for key := range values { keys = append(keys, key) }
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := cfg.Set(key, values[key]); err != nil { return fmt.Errorf("%s: %w", path, err) }
+	}
+	return nil
+}
+
+// Applies a --set section.key=value override, value uses the config file syntax
+// but plain strings do not need quotes
+func (cfg *Config) SetString(assignment string) error {
+	key, raw, ok := strings.Cut(assignment, "=")
+	if !ok { return fmt.Errorf("invalid setting %q, expected key=value", assignment) }
+	value, err := parseConfigValue(strings.TrimSpace(raw))
+	if err != nil { value = strings.TrimSpace(raw) }
+	return cfg.Set(strings.TrimSpace(key), value)
+}
+
+// Sets a single value, key is "name" for top level settings or "section.name"
+func (cfg *Config) Set(key string, value interface{}) error {
+	section, name, found := strings.Cut(key, ".")
+	if !found { section, name = "", key }
+
+	var err error
+	switch section {
+	case "":
+		switch name {
+		case "path":
+			cfg.Path, err = configString(value)
+			cfg.Path = expandHome(cfg.Path)
+		case "week_start":
+			var str string
+			if str, err = configString(value); err == nil { cfg.WeekStart, err = parseWeekStart(str) }
+		case "editor":
+			cfg.Editor, err = configString(value)
+		case "time_format":
+			var str string
+			if str, err = configString(value); err == nil { cfg.Clock12h, err = parseTimeFormat(str) }
+		case "search_years":
+			cfg.SearchYears, err = configInt(value, 0, 50)
+		default:
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	case "remind":
+		switch name {
+		case "command":
+			cfg.RemindCommand, err = configString(value)
+			cfg.RemindCommand = expandHome(cfg.RemindCommand)
+		case "args":
+			cfg.RemindArgs, err = configStrings(value)
+		default:
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	case "panes":
+		var b bool
+		b, err = configBool(value)
+		switch name {
+		case "today":
+			cfg.TodayPane = b
+		case "week":
+			cfg.WeekPane = b
+		case "debug":
+			cfg.Debug = b
+		default:
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	case "colors":
+		if _, ok := cfg.Colors[name]; !ok { return fmt.Errorf("unknown color %q", key) }
+		var str string
+		if str, err = configString(value); err == nil { cfg.Colors[name], err = parseColorSpec(str) }
+	case "tag_colors":
+		var str string
+		if str, err = configString(value); err == nil { cfg.TagColors[name], err = parseColorSpec(str) }
+	case "keys":
+		cfg.Keys[name], err = configStrings(value)
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if err != nil { return fmt.Errorf("%s: %w", key, err) }
+	return nil
+}
+
+// Command line flag that records a setting, applied in order after the config file
+// key is empty for --set which takes key=value itself
+type settingFlag struct {
+	key string
+	overrides *[]string
+	isBool bool
+}
+
+func (f settingFlag) String() string { return "" }
+func (f settingFlag) IsBoolFlag() bool { return f.isBool }
+func (f settingFlag) Set(value string) error {
+	if f.key != "" { value = f.key + "=" + value }
+	*f.overrides = append(*f.overrides, value)
+	return nil
+}
+
+// Makes the settings effective, called once before the ui starts
+func (cfg *Config) Apply() {
+	firstWeekday = cfg.WeekStart
+	remindCommand = cfg.RemindCommand
+	remindArgs = cfg.RemindArgs
+	editorTemplate = cfg.Editor
+	clock12h = cfg.Clock12h
+	searchYears = cfg.SearchYears
+	for tag, color := range cfg.TagColors { tagColors[tag] = color }
+	eventsFg = cfg.Colors["events"].Fg
+	todayFg = cfg.Colors["today"].Fg
+}
+
+// Initializes the color pairs from the configured colors, needs Start_color
+func (cfg *Config) InitColors() {
+	for _, setting := range colorSettings {
+		color := cfg.Colors[setting.name]
+		Init_pair(setting.pair, color.Fg, color.Bg)
+	}
+	initTagColors()
+}
+
+// "red", "white on blue", "208" or "default on 17"
+func parseColorSpec(str string) (ColorSpec, error) {
+	fg, bg, hasBg := strings.Cut(str, " on ")
+	spec := ColorSpec{-1, -1}
+	var err error
+	if spec.Fg, err = parseColor(fg); err != nil { return spec, err }
+	if hasBg {
+		if spec.Bg, err = parseColor(bg); err != nil { return spec, err }
+	}
+	return spec, nil
+}
+
+func parseColor(str string) (int, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	if color, ok := colorNames[str]; ok { return color, nil }
+	color, err := strconv.Atoi(str)
+	if err != nil || color < 0 || color > 255 { return 0, fmt.Errorf("invalid color %q", str) }
+	return color, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") { return path }
+	home, err := os.UserHomeDir()
+	if err != nil { return path }
+	return filepath.Join(home, path[1:])
+}
+
+func configString(value interface{}) (string, error) {
+	str, ok := value.(string)
+	if !ok { return "", fmt.Errorf("expected a string") }
+	return str, nil
+}
+
+// a single string is accepted as list with one element
+func configStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	}
+	return nil, fmt.Errorf("expected a list of strings")
+}
+
+// integer between min and max
+func configInt(value interface{}, min int, max int) (int, error) {
+	str, ok := value.(string)
+	n, err := strconv.Atoi(str)
+	if !ok || err != nil { return 0, fmt.Errorf("expected a number") }
+	if n < min || n > max { return 0, fmt.Errorf("expected a number from %d to %d", min, max) }
+	return n, nil
+}
+
+func configBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil { return b, nil }
+	}
+	return false, fmt.Errorf("expected true or false")
+}
+
+///////////////// PARSER ////////////////////////////////
+
+// Parses the subset of TOML remindcal uses: [sections], key = value with strings,
+// integers, booleans and arrays of strings. Returns values by "section.key"
+func parseConfig(scanner *bufio.Scanner) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	section := ""
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(stripConfigComment(scanner.Text()))
+		// arrays may span multiple lines
+		for strings.HasSuffix(line, "[") || (strings.Contains(line, "= [") && !strings.HasSuffix(line, "]")) {
+			if !scanner.Scan() { break }
+			lineno++
+			line += " " + strings.TrimSpace(stripConfigComment(scanner.Text()))
+		}
+		if line == "" { continue }
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") { return nil, fmt.Errorf("line %d: invalid section %s", lineno, line) }
+			section = strings.TrimSpace(line[1:len(line)-1])
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok { return nil, fmt.Errorf("line %d: expected key = value", lineno) }
+		key = strings.TrimSpace(key)
+		if unquoted, err := strconv.Unquote(key); err == nil { key = unquoted }
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil { return nil, fmt.Errorf("line %d: %w", lineno, err) }
+		if section != "" { key = section + "." + key }
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+func parseConfigValue(raw string) (interface{}, error) {
+	switch {
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case strings.HasPrefix(raw, "\""):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) >= 2:
+		return raw[1:len(raw)-1], nil // literal string
+	case strings.HasPrefix(raw, "[") && strings.HasSuffix(raw, "]"):
+		list := []string{}
+		for _, item := range splitConfigArray(raw[1:len(raw)-1]) {
+			value, err := parseConfigValue(item)
+			if err != nil { return nil, err }
+			str, ok := value.(string)
+			if !ok { return nil, fmt.Errorf("arrays may only contain strings") }
+			list = append(list, str)
+		}
+		return list, nil
+	}
+	// integers are kept as strings, color numbers and configInt parse them
+	if _, err := strconv.Atoi(raw); err == nil { return raw, nil }
+	return nil, fmt.Errorf("invalid value %s", raw)
+}
+
+// splits array items at commas outside of strings
+func splitConfigArray(str string) []string {
+	items := []string{}
+	start := 0
+	var quote rune
+	for i, r := range str {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '\'' || i == 0 || str[i-1] != '\\') { quote = 0 }
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, strings.TrimSpace(str[start:i]))
+			start = i+1
+		}
+	}
+	if last := strings.TrimSpace(str[start:]); last != "" { items = append(items, last) }
+	return items
+}
+
+// removes a # comment that is not part of a string
+func stripConfigComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '\'' || line[i-1] != '\\') { quote = 0 }
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
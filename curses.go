@@ -6,13 +6,15 @@ package main
 #include <stdlib.h>
 #include <stdio.h>
 #include <locale.h>
+#include <langinfo.h>
 #include <ncurses.h>
 
+// text is never used as format string so messages may contain %
 int go_mvprintw(int y, int x, char *name) {
-	return mvprintw(y, x, name);
+	return mvprintw(y, x, "%s", name);
 }
 int go_mvwprintw(WINDOW *win, int y, int x, char *name) {
-	return mvwprintw(win, y, x, name);
+	return mvwprintw(win, y, x, "%s", name);
 }
 
 // normally a macro but to make it go ready we use pointers instead
@@ -59,6 +61,10 @@ func Curs_set(visibility int) {
 func Halfdelay(tenth int) {
 	C.halfdelay(C.int(tenth));
 }
+// milliseconds to wait after ESC for the rest of an escape sequence
+func Set_escdelay(ms int) {
+	C.set_escdelay(C.int(ms))
+}
 
 func Mvprintw(y int, x int, text string) {
 	cs := C.CString(text)
@@ -73,6 +79,9 @@ func Mvwprintw(w *Window, y int, x int, text string) {
 func Refresh() {
 	C.refresh()
 }
+func Clear() {
+	C.clear()
+}
 func Endwin() {
 	C.endwin()
 }
@@ -84,6 +93,13 @@ const KEY_UP     = C.KEY_UP
 const KEY_LEFT   = C.KEY_LEFT
 const KEY_DOWN   = C.KEY_DOWN
 const KEY_RIGHT  = C.KEY_RIGHT
+const KEY_BACKSPACE = C.KEY_BACKSPACE
+const KEY_ENTER  = C.KEY_ENTER
+const KEY_DC     = C.KEY_DC
+const KEY_NPAGE  = C.KEY_NPAGE
+const KEY_PPAGE  = C.KEY_PPAGE
+const KEY_HOME   = C.KEY_HOME
+const KEY_END    = C.KEY_END
 
 ///////////////// WINDOW ////////////////////
 func Newwin(h int, w int, y int, x int) (window *Window, err error) {
@@ -110,6 +126,12 @@ func (w *Window) Refresh() {
 func (w *Window) Erase() {
 	C.werase(w.win)
 }
+func (w *Window) Move(y int, x int) {
+	C.wmove(w.win, C.int(y), C.int(x))
+}
+func (w *Window) Delete() {
+	C.delwin(w.win)
+}
 
 ///////////////// BOX ///////////////////////
 const ACS_ULCORNER      = C.A_ALTCHARSET + 'l'
@@ -145,6 +167,8 @@ func (w *Window) Box(vch, hch int) error {
 
 ///////////////// COLOR ///////////////////
 const A_BOLD   = int(C.A_BOLD)
+const A_REVERSE = int(C.A_REVERSE)
+const A_UNDERLINE = int(C.A_UNDERLINE)
 
 const COLOR_BLACK   = 0
 const COLOR_RED     = 1
@@ -164,6 +188,14 @@ func Use_default_colors() {
 func Init_pair(pair int, f int, b int) {
 	C.init_pair(C.short(pair), C.short(f), C.short(b))
 }
+// like Init_pair but for color numbers above 32767 e.g. direct colors
+func Init_extended_pair(pair int, f int, b int) {
+	C.init_extended_pair(C.int(pair), C.int(f), C.int(b))
+}
+// number of colors the terminal supports, 1<<24 for direct color terminals
+func COLORS() int {
+	return int(C.COLORS)
+}
 func COLOR_PAIR(n int) int {
 	return int(C.go_COLOR_PAIR(C.int(n)))
 }
@@ -189,3 +221,7 @@ func Setlocale(category int, locale string) {
 	C.setlocale(C.int(category), cs)
 	C.free(unsafe.Pointer(cs))
 }
+// abbreviated weekday name of the current locale, 0 is Sunday
+func Weekday_abbr(wd int) string {
+	return C.GoString(C.nl_langinfo(C.ABDAY_1 + C.nl_item(wd)))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Draws the events of d on a 24 hour timeline, overlapping events are placed
+// side by side and marked with "!", untimed events are listed above the timeline
+// If d is the day of now a line marks the current time
+// eventSelection is the index of the selected event of day d ( -1 for none )
+func drawDay(
+	win *Window,
+	h int, w int, y int, x int, active bool,
+	d Date, now time.Time, events map[string][]Event, eventSelection int,
+	) {
+	labelWidth := hourLabelWidth() // "09:00 "
+	dayEvents := events[d.NumericString()]
+
+	if active { Wattron(win, COLOR_PAIR(1)) }
+	drawBox(win, h, w, y, x)
+	Wattroff(win, COLOR_PAIR(1))
+
+	timelineWidth := w - 2 - labelWidth
+	if timelineWidth < 3 || h < 6 { return }
+
+	wd := time.Weekday(Weekday(d.Year, time.Month(d.Month), d.Day)).String()
+	dayLabel := fmt.Sprintf(" %s %d %s %d ", wd, d.Day, time.Month(d.Month).String(), d.Year)
+	Wattron(win, COLOR_PAIR(1))
+	Mvwprintw(win, y, x+2, trimMessage(dayLabel, w-4))
+	Wattroff(win, COLOR_PAIR(1))
+
+	// all day strip
+	row := y+1
+	allDayRows := countUntimed(dayEvents)
+	if allDayRows < 1 { allDayRows = 1 }
+	if allDayRows > 3 { allDayRows = 3 }
+	r := 0
+	for ei, e := range dayEvents {
+		if e.IsTimed() { continue }
+		if r == allDayRows-1 && countUntimed(dayEvents[ei:]) > 1 {
+			Mvwprintw(win, row+r, x+1+labelWidth, trimMessage(fmt.Sprintf("+%d more", countUntimed(dayEvents[ei:])), timelineWidth-1))
+			break
+		}
+		attrs := eventAttrs(e)
+		if ei == eventSelection { attrs = COLOR_PAIR(1) | A_BOLD }
+		Wattron(win, attrs)
+		title := e.Title()
+		if e.InSpan() { title += " ( " + spanLabel(e) + " )" }
+		Mvwprintw(win, row+r, x+1+labelWidth, trimMessage(title, timelineWidth-1))
+		Wattroff(win, attrs)
+		r++
+	}
+	Mvwprintw(win, row, x+1, "all")
+	row += allDayRows
+	Mvwhline(win, row, x+1, ACS_HLINE, w-2)
+	row++
+
+	// hour grid, if there is not enough space for 24 hours only part of the day is shown
+	gridHeight := y+h-1-row
+	if gridHeight < 1 { return }
+	rowsPerHour := gridHeight / 24
+	if rowsPerHour < 1 { rowsPerHour = 1 }
+	visibleHours := gridHeight / rowsPerHour
+	startHour := weekGridStartHour([7][]Event{dayEvents}, [7]Date{d}, d, eventSelection, visibleHours)
+
+	for r := 0; r < gridHeight; r++ {
+		if r % rowsPerHour != 0 { continue }
+		hour := startHour + r/rowsPerHour
+		if hour > 23 { break }
+		Wattron(win, COLOR_PAIR(1))
+		Mvwprintw(win, row+r, x+1, formatHour(hour))
+		Wattroff(win, COLOR_PAIR(1))
+	}
+
+	// current time, drawn first so the events stay readable
+	if d.Year == now.Year() && d.Month == int(now.Month()) && d.Day == now.Day() {
+		minutes := now.Hour()*60 + now.Minute()
+		r := (minutes - startHour*60) * rowsPerHour / 60
+		if r >= 0 && r < gridHeight {
+			Wattron(win, COLOR_PAIR(1) | A_BOLD)
+			Mvwprintw(win, row+r, x+1, formatClock(minutes))
+			Mvwhline(win, row+r, x+1+labelWidth, ACS_HLINE, timelineWidth-1)
+			Wattroff(win, COLOR_PAIR(1) | A_BOLD)
+		}
+	}
+
+	conflicting := map[int]bool{}
+	for _, pair := range timelineConflicts(dayEvents) {
+		conflicting[pair[0]] = true
+		conflicting[pair[1]] = true
+	}
+
+	blocks := layoutTimeline(dayEvents, func(e Event) (int, int) {
+		top := (e.Time - startHour*60) * rowsPerHour / 60
+		height := eventMinutes(e) * rowsPerHour / 60
+		if height < 1 { height = 1 }
+		return top, top+height
+	})
+	for _, b := range blocks {
+		e := dayEvents[b.index]
+		colWidth := timelineWidth / b.columns
+		left := x+1+labelWidth + b.column*colWidth
+		if b.column == b.columns-1 { colWidth = timelineWidth - b.column*colWidth } // remainder goes to the last column
+
+		attrs := COLOR_PAIR(6)
+		if b.index == eventSelection { attrs = COLOR_PAIR(7) | A_BOLD }
+		text := formatTimeRange(e) + " " + e.Title()
+		if conflicting[b.index] { text = "! " + text }
+
+		Wattron(win, attrs)
+		for r := b.top; r < b.bottom; r++ {
+			if r < 0 { continue }
+			if r >= gridHeight { break }
+			line := ""
+			if r == b.top || (b.top < 0 && r == 0) { line = text }
+			Mvwprintw(win, row+r, left, padMessage(line, colWidth-1))
+		}
+		Wattroff(win, attrs)
+	}
+}
+
+// Length of a timed event in minutes, 0 if it has no duration
+func eventMinutes(e Event) int {
+	duration := e.EventDuration
+	if duration < 0 { duration = e.Duration }
+	if duration < 0 { duration = 0 }
+	return duration
+}
+
+type timelineBlock struct {
+	index int // index of the event in the day
+	top int
+	bottom int // exclusive
+	column int
+	columns int // columns of the group of overlapping blocks the block is part of
+}
+
+// Places the timed events in columns so overlapping blocks are side by side
+// span returns the rows ( top, bottom exclusive ) an event takes up
+// Blocks that overlap directly or through others share the same number of columns
+func layoutTimeline(events []Event, span func(Event) (int, int)) []timelineBlock {
+	blocks := []timelineBlock{}
+	for i, e := range events {
+		if !e.IsTimed() { continue }
+		top, bottom := span(e)
+		blocks = append(blocks, timelineBlock{index: i, top: top, bottom: bottom})
+	}
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].top < blocks[j].top })
+
+	groupStart := 0
+	groupBottom := 0
+	columnBottoms := []int{} // bottom of the last block in each column of the current group
+	finishGroup := func(end int) {
+		for i := groupStart; i < end; i++ { blocks[i].columns = len(columnBottoms) }
+	}
+	for i := range blocks {
+		if i > 0 && blocks[i].top >= groupBottom {
+			finishGroup(i)
+			groupStart = i
+			columnBottoms = columnBottoms[:0]
+		}
+		column := -1
+		for c, bottom := range columnBottoms {
+			if bottom <= blocks[i].top { column = c; break }
+		}
+		if column < 0 {
+			column = len(columnBottoms)
+			columnBottoms = append(columnBottoms, 0)
+		}
+		columnBottoms[column] = blocks[i].bottom
+		blocks[i].column = column
+		if i == groupStart || blocks[i].bottom > groupBottom { groupBottom = blocks[i].bottom }
+	}
+	finishGroup(len(blocks))
+	return blocks
+}
+
+// Pairs of indices of timed events whose times overlap
+// Events without a duration conflict with events running at their start time
+func timelineConflicts(events []Event) [][2]int {
+	conflicts := [][2]int{}
+	for i, a := range events {
+		if !a.IsTimed() { continue }
+		for j := i+1; j < len(events); j++ {
+			b := events[j]
+			if !b.IsTimed() { continue }
+			aEnd, bEnd := a.Time+eventMinutes(a), b.Time+eventMinutes(b)
+			if aEnd == a.Time { aEnd++ }
+			if bEnd == b.Time { bEnd++ }
+			if a.Time < bEnd && b.Time < aEnd { conflicts = append(conflicts, [2]int{i, j}) }
+		}
+	}
+	return conflicts
+}
+
+// Status line message for the conflicts of the day, empty if there are none
+func conflictMessage(events []Event) string {
+	conflicts := timelineConflicts(events)
+	if len(conflicts) == 0 { return "" }
+	a, b := events[conflicts[0][0]], events[conflicts[0][1]]
+	message := fmt.Sprintf("Conflict: %s %s overlaps %s %s", formatClock(a.Time), a.Title(), formatClock(b.Time), b.Title())
+	if len(conflicts) > 1 { message = fmt.Sprintf("%d conflicts, %s", len(conflicts), message) }
+	return message
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Modal input form drawn centered over the other windows
+// Fields with Options cycle through them with left/right instead of text input
+type Form struct {
+	Title string
+	Fields []FormField
+	Err string // shown below the fields e.g. after failed validation
+	selected int
+}
+
+type FormField struct {
+	Label string
+	Value string
+	Options []string
+}
+
+func (f *Form) Value(label string) string {
+	for _, field := range f.Fields {
+		if field.Label == label { return field.Value }
+	}
+	return ""
+}
+
+// Runs the form until it is submitted with Enter ( returns true ) or canceled with ESC
+// validate is called on submit, if it returns an error the form stays open
+// and shows it. Callers have to redraw all windows afterwards
+func runForm(rows int, cols int, form *Form, validate func(*Form) error) bool {
+	labelWidth := 0
+	for _, field := range form.Fields {
+		if len(field.Label) > labelWidth { labelWidth = len(field.Label) }
+	}
+	h := len(form.Fields) + 5
+	w := cols - 8
+	if w > 72 { w = 72 }
+	if h > rows || w < labelWidth + 10 { return false }
+
+	win, err := Newwin(h, w, (rows-h)/2, (cols-w)/2)
+	if err != nil { return false }
+	defer win.Delete()
+	defer Curs_set(0)
+	Curs_set(1)
+
+	for {
+		win.Erase()
+		drawForm(win, h, w, labelWidth, form)
+		win.Refresh()
+
+		c := Getch()
+		field := &form.Fields[form.selected]
+		switch(c) {
+			case 27: // ESC
+				return false
+			case '\n', '\r', KEY_ENTER:
+				if form.selected < len(form.Fields)-1 { form.selected++; continue }
+				form.Err = ""
+				if validate == nil { return true }
+				if err := validate(form); err != nil {
+					form.Err = err.Error()
+					continue
+				}
+				return true
+			case 9, KEY_DOWN:
+				form.selected = (form.selected + 1) % len(form.Fields)
+			case KEY_UP:
+				form.selected = (form.selected + len(form.Fields) - 1) % len(form.Fields)
+			case KEY_LEFT, KEY_RIGHT:
+				if len(field.Options) == 0 { continue }
+				i := 0
+				for oi, option := range field.Options {
+					if option == field.Value { i = oi }
+				}
+				if c == KEY_RIGHT { i++ } else { i += len(field.Options) - 1 }
+				field.Value = field.Options[i % len(field.Options)]
+			case KEY_BACKSPACE, 127, 8:
+				if len(field.Options) > 0 || field.Value == "" { continue }
+				_, size := utf8.DecodeLastRuneInString(field.Value)
+				field.Value = field.Value[:len(field.Value)-size]
+			case 21: // CTRL-U
+				if len(field.Options) == 0 { field.Value = "" }
+			case -1: // skip ERR ( see halfdelay )
+			default:
+				// multibyte characters arrive byte by byte
+				if c >= 32 && c < 256 && len(field.Options) == 0 { field.Value += string([]byte{byte(c)}) }
+		}
+	}
+}
+
+func drawForm(win *Window, h int, w int, labelWidth int, form *Form) {
+	Wattron(win, COLOR_PAIR(1))
+	drawBox(win, h, w, 0, 0)
+	Mvwprintw(win, 0, 2, trimMessage(" " + form.Title + " ", w-4))
+	Wattroff(win, COLOR_PAIR(1))
+
+	maxValue := w - labelWidth - 6
+	cursorY, cursorX := 0, 0
+	for i, field := range form.Fields {
+		Mvwprintw(win, 2+i, 2, fmt.Sprintf("%*s", labelWidth, field.Label))
+		value := field.Value
+		if len(field.Options) > 0 { value = "< " + value + " >" }
+		// show the end of long values since that is where the input happens
+		if stringWidth(value) > maxValue { value = cutWidthLeft(value, maxValue) }
+		attrs := 0
+		if i == form.selected { attrs = A_REVERSE }
+		Wattron(win, attrs)
+		Mvwprintw(win, 2+i, labelWidth+4, padMessage(value, maxValue))
+		Wattroff(win, attrs)
+		if i == form.selected { cursorY, cursorX = 2+i, labelWidth+4+stringWidth(value) }
+	}
+	if form.Err != "" {
+		Wattron(win, COLOR_PAIR(1))
+		Mvwprintw(win, h-2, 2, trimMessage(form.Err, w-4))
+		Wattroff(win, COLOR_PAIR(1))
+	} else {
+		Mvwprintw(win, h-2, 2, trimMessage("TAB/Up/Down:Field  Left/Right:Option  Enter:Next/Save  ESC:Cancel", w-4))
+	}
+	win.Move(cursorY, cursorX)
+}
+
+// Asks a yes/no question in a centered window, only 'y' confirms
+func confirm(rows int, cols int, question string) bool {
+	w := stringWidth(question) + 6
+	if w > cols-4 { w = cols-4 }
+	h := 5
+	if w < 12 || h > rows { return false }
+
+	win, err := Newwin(h, w, (rows-h)/2, (cols-w)/2)
+	if err != nil { return false }
+	defer win.Delete()
+
+	Wattron(win, COLOR_PAIR(1))
+	drawBox(win, h, w, 0, 0)
+	Wattroff(win, COLOR_PAIR(1))
+	Mvwprintw(win, 1, 2, trimMessage(question, w-4))
+	Mvwprintw(win, 3, 2, trimMessage("y:Yes  n:No", w-4))
+	win.Refresh()
+
+	for {
+		switch(Getch()) {
+			case 'y', 'Y': return true
+			case -1: // skip ERR ( see halfdelay )
+			default: return false
+		}
+	}
+}
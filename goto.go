@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parses the input of the go to date prompt and --date, relative dates are based on base
+//
+//	2027-03-01, March 2027, 5 March, Mar 5 2027
+//	today, tomorrow, yesterday
+//	+3w, -2d, +1m, +1y ( days if the unit is left out )
+//	friday, next friday, last monday
+//
+// Anything else is evaluated as remind expression e.g. easterdate(2027)
+func parseGotoDate(input string, base Date, today Date) (Date, error) {
+	str := strings.ToLower(strings.TrimSpace(input))
+	switch str {
+	case "", "today":
+		return today, nil
+	case "tomorrow":
+		today.AddDay()
+		return today, nil
+	case "yesterday":
+		today.SubtractDay()
+		return today, nil
+	}
+	if isoDateRegex.MatchString(str) { return parseISODate(str) }
+	if d, ok := parseRelativeDate(str, base); ok { return d, nil }
+	if d, ok := parseWeekdayDate(str, base); ok { return d, nil }
+	if d, ok, err := parseMonthDate(str, base); ok { return d, err }
+	return evalRemindDate(input, base)
+}
+
+var relativeDateRegex = regexp.MustCompile(`^([+-])\s*(\d+)\s*([dwmy]?)$`)
+
+func parseRelativeDate(str string, d Date) (Date, bool) {
+	m := relativeDateRegex.FindStringSubmatch(str)
+	if m == nil { return d, false }
+	n, err := strconv.Atoi(m[2])
+	if err != nil || n > 100000 { return d, false }
+	forward := m[1] == "+"
+	switch m[3] {
+	case "", "d", "w":
+		if m[3] == "w" { n *= 7 }
+		for i := 0; i < n; i++ {
+			if forward { d.AddDay() } else { d.SubtractDay() }
+		}
+	case "m", "y":
+		if m[3] == "y" { n *= 12 }
+		if !forward { n = -n }
+		result, err := addMonths(d, n)
+		if err != nil { return d, false }
+		d = result
+	}
+	return d, true
+}
+
+// Moves d by n months in one step, the day is clamped once to the length of the
+// resulting month so Jan 31 +2m is Mar 31 and not Mar 28
+func addMonths(d Date, n int) (Date, error) {
+	months := d.Year*12 + d.Month-1 + n
+	if months < 0 { return d, fmt.Errorf("Date out of range") }
+	year, month := months/12, months%12+1
+	day := d.Day
+	if max := DaysInMonth(year, time.Month(month)); day > max { day = max }
+	return NewDate(year, month, day)
+}
+
+// "friday" and "next friday" are the next friday after d, "last friday" the one before
+func parseWeekdayDate(str string, d Date) (Date, bool) {
+	fields := strings.Fields(str)
+	forward := true
+	if len(fields) == 2 && (fields[0] == "next" || fields[0] == "last") {
+		forward = fields[0] == "next"
+		fields = fields[1:]
+	}
+	if len(fields) != 1 { return d, false }
+	wd, ok := parseWeekdayName(fields[0])
+	if !ok { return d, false }
+	for i := 0; i < 7; i++ {
+		if forward { d.AddDay() } else { d.SubtractDay() }
+		if Weekday(d.Year, time.Month(d.Month), d.Day) == int(wd) { break }
+	}
+	return d, true
+}
+
+func parseWeekdayName(str string) (time.Weekday, bool) {
+	if len(str) < 3 { return 0, false }
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.HasPrefix(strings.ToLower(wd.String()), str) { return wd, true }
+	}
+	return 0, false
+}
+
+func parseMonthName(str string) (int, bool) {
+	if len(str) < 3 { return 0, false }
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), str) { return int(m), true }
+	}
+	return 0, false
+}
+
+// "march", "march 2027", "march 5", "5 march 2027", "mar 5, 2027"
+// ok is false if str does not contain a month name, the day defaults to the 1st
+// and the year to the year of d
+func parseMonthDate(str string, d Date) (Date, bool, error) {
+	fields := strings.FieldsFunc(str, func(r rune) bool { return r == ' ' || r == ',' || r == '.' })
+	if len(fields) == 0 || len(fields) > 3 { return d, false, nil }
+	month, day, year := 0, 1, d.Year
+	for _, field := range fields {
+		if m, ok := parseMonthName(field); ok && month == 0 {
+			month = m
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil { return d, false, nil }
+		if n > 31 || len(field) == 4 { year = n } else { day = n }
+	}
+	if month == 0 { return d, false, nil }
+	if day < 1 || day > DaysInMonth(year, time.Month(month)) {
+		return d, true, fmt.Errorf("Invalid day %d in %s %d", day, time.Month(month), year)
+	}
+	result, err := NewDate(year, month, day)
+	return result, true, err
+}
+
+// Lets remind evaluate expr with today() being d, the result is coerced to a DATE
+func evalRemindDate(expr string, d Date) (Date, error) {
+	if strings.ContainsAny(expr, "\n\r") { return d, fmt.Errorf("Invalid date %q", expr) }
+	script := "BANNER %\n" +
+		"SET remindcal_date coerce(\"DATE\", " + expr + ")\n" +
+		"MSG [year(remindcal_date)] [monno(remindcal_date)] [day(remindcal_date)]%\n"
+	out, err := runRemindInput(script, "-", remDate(d))
+	if err != nil { return d, fmt.Errorf("Could not read date %q", expr) }
+	var year, month, day int
+	if _, err := fmt.Sscan(strings.TrimSpace(out), &year, &month, &day); err != nil {
+		return d, fmt.Errorf("Could not read date %q", expr)
+	}
+	return NewDate(year, month, day)
+}
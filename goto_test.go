@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestParseRelativeDate(t *testing.T) {
+	tests := []struct {
+		base string
+		input string
+		want string
+	}{
+		{"2026-01-31", "+2m", "2026-03-31"},
+		{"2026-01-31", "+1m", "2026-02-28"},
+		{"2026-01-31", "+12m", "2027-01-31"},
+		{"2026-01-31", "+1y", "2027-01-31"},
+		{"2024-02-29", "+1y", "2025-02-28"},
+		{"2024-02-29", "+4y", "2028-02-29"},
+		{"2026-03-31", "-1m", "2026-02-28"},
+		{"2026-03-31", "-2m", "2026-01-31"},
+		{"2026-01-15", "-1m", "2025-12-15"},
+		{"2026-12-31", "+3d", "2027-01-03"},
+		{"2026-10-17", "+2w", "2026-10-31"},
+		{"2026-10-17", "-10", "2026-10-07"},
+	}
+	for _, test := range tests {
+		base, err := parseISODate(test.base)
+		if err != nil { t.Fatal(err) }
+		got, ok := parseRelativeDate(test.input, base)
+		if !ok { t.Errorf("parseRelativeDate(%q, %s) failed", test.input, test.base); continue }
+		if remDate(got) != test.want { t.Errorf("parseRelativeDate(%q, %s) = %s, want %s", test.input, test.base, remDate(got), test.want) }
+	}
+}
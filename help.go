@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Help overlay listing the commands with their current keys, grouped by the
+// window they apply to. Descriptions fall back to Command.Description
+type helpSection struct {
+	title string
+	entries []helpEntry
+}
+
+type helpEntry struct {
+	command string
+	description string
+}
+
+var helpSections = []helpSection{
+	{"Calendar", []helpEntry{
+		{"left", "Previous day"},
+		{"right", "Next day"},
+		{"down", "Next week"},
+		{"up", "Previous week"},
+		{"next-month", ""},
+		{"prev-month", ""},
+	}},
+	{"Year overview", []helpEntry{
+		{"left", "Previous day"},
+		{"right", "Next day"},
+		{"down", "Next week"},
+		{"up", "Previous week"},
+		{"next-month", ""},
+		{"prev-month", ""},
+		{"open-day", ""},
+	}},
+	{"Events", []helpEntry{
+		{"down", "Next event, continues with the next day"},
+		{"up", "Previous event, continues with the previous day"},
+		{"left", "Previous day ( week and day view )"},
+		{"right", "Next day ( week and day view )"},
+		{"edit", "Open the selected event in the editor"},
+		{"delete", ""},
+		{"move", ""},
+		{"next-hit", ""},
+		{"prev-hit", ""},
+		{"close-search", ""},
+	}},
+	{"Today", []helpEntry{
+		{"down", "Scroll down"},
+		{"up", "Scroll up"},
+	}},
+	{"Everywhere", []helpEntry{
+		{"next-window", "Switch between calendar, events and today window"},
+		{"quit", ""},
+		{"help", ""},
+		{"add", ""},
+		{"week-view", ""},
+		{"day-view", ""},
+		{"year-view", ""},
+		{"today-window", ""},
+		{"search", ""},
+		{"tag-filter", ""},
+		{"next-day", ""},
+		{"prev-day", ""},
+		{"next-week", ""},
+		{"prev-week", ""},
+		{"today", ""},
+		{"goto-date", ""},
+	}},
+}
+
+// Lines of the overlay for the bindings of keymap
+func helpLines(keymap *Keymap) []string {
+	keysWidth := 0
+	for _, section := range helpSections {
+		for _, entry := range section.entries {
+			keys := strings.Join(keymap.Keys(entry.command), " ")
+			if stringWidth(keys) > keysWidth { keysWidth = stringWidth(keys) }
+		}
+	}
+
+	lines := []string{"A number before a movement repeats it e.g. 3j", ""}
+	for _, section := range helpSections {
+		lines = append(lines, section.title)
+		for _, entry := range section.entries {
+			keys := strings.Join(keymap.Keys(entry.command), " ")
+			if keys == "" { keys = "-" }
+			description := entry.description
+			if description == "" {
+				command, _ := findCommand(entry.command)
+				description = command.Description
+			}
+			lines = append(lines, "  " + padMessage(keys, keysWidth) + "  " + description)
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// Shows the help overlay until it is closed with ESC, q or ?
+// Callers have to redraw all windows afterwards
+func runHelp(rows int, cols int, keymap *Keymap) {
+	lines := helpLines(keymap)
+	h := rows - 4
+	if h > len(lines)+3 { h = len(lines)+3 }
+	w := cols - 8
+	if w > 80 { w = 80 }
+	if h < 5 || w < 20 { return }
+
+	win, err := Newwin(h, w, (rows-h)/2, (cols-w)/2)
+	if err != nil { return }
+	defer win.Delete()
+
+	visible := h-3
+	maxOffset := len(lines) - visible
+	if maxOffset < 0 { maxOffset = 0 }
+	offset := 0
+	for {
+		win.Erase()
+		Wattron(win, COLOR_PAIR(1))
+		drawBox(win, h, w, 0, 0)
+		Mvwprintw(win, 0, 2, " Help ")
+		Wattroff(win, COLOR_PAIR(1))
+		for i := 0; i < visible && offset+i < len(lines); i++ {
+			line := lines[offset+i]
+			attrs := 0
+			if line != "" && line[0] != ' ' && i+offset > 1 { attrs = COLOR_PAIR(1) | A_BOLD } // section title
+			Wattron(win, attrs)
+			Mvwprintw(win, 1+i, 2, trimMessage(line, w-4))
+			Wattroff(win, attrs)
+		}
+		hint := "ESC:Close"
+		if maxOffset > 0 { hint = fmt.Sprintf("j/k:Scroll  %s  ( %d/%d )", hint, offset+visible, len(lines)) }
+		Mvwprintw(win, h-2, 2, trimMessage(hint, w-4))
+		win.Refresh()
+
+		c := Getch()
+		command, count, _ := keymap.Feed(c)
+		switch {
+			case command == "quit" || command == "help" || command == "close-search" || c == 27:
+				return
+			case command == "down" || c == KEY_DOWN:
+				offset += count
+			case command == "up" || c == KEY_UP:
+				offset -= count
+			case c == KEY_NPAGE || c == ' ':
+				offset += visible
+			case c == KEY_PPAGE:
+				offset -= visible
+		}
+		if offset > maxOffset { offset = maxOffset }
+		if offset < 0 { offset = 0 }
+	}
+}
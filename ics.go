@@ -0,0 +1,211 @@
+package main
+
+import (
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// remindcal export-ics FILE --from DATE --to DATE [-o OUT]
+// Writes the reminders remind computes for the date range as RFC 5545 VCALENDAR
+func exportICSCommand(args []string) int {
+	fs := flag.NewFlagSet("export-ics", flag.ContinueOnError)
+	from := fs.String("from", "", "first day YYYY-MM-DD ( default today )")
+	to := fs.String("to", "", "last day YYYY-MM-DD ( default one year after --from )")
+	output := fs.String("o", "", "output file ( default stdout )")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remindcal export-ics FILE [--from DATE] [--to DATE] [-o OUT]\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil { return 2 }
+	if len(positional) != 1 { fs.Usage(); return 2 }
+	filename := positional[0]
+
+	start, err := dateOrToday(*from)
+	if err != nil { fmt.Fprintf(os.Stderr, "remindcal: %s\n", err); return 2 }
+	end := start
+	if *to == "" {
+		end.Year++
+		if end.Month == 2 && end.Day == 29 { end.Day = 28 }
+	} else if end, err = parseISODate(*to); err != nil {
+		fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+		return 2
+	}
+	if dateBefore(end, start) { fmt.Fprintf(os.Stderr, "remindcal: --to is before --from\n"); return 2 }
+	days := daysBetween(start, end) + 1
+
+	events, err := getEventsRange(filename, start, days)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+		if events == nil { return 1 }
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil { fmt.Fprintf(os.Stderr, "remindcal: %s\n", err); return 1 }
+		defer f.Close()
+		w = f
+	}
+	if err := writeICS(w, start, days, events, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// number of days from a to b
+func daysBetween(a Date, b Date) int {
+	ta := time.Date(a.Year, time.Month(a.Month), a.Day, 0, 0, 0, 0, time.UTC)
+	tb := time.Date(b.Year, time.Month(b.Month), b.Day, 0, 0, 0, 0, time.UTC)
+	return int(tb.Sub(ta).Hours() / 24)
+}
+
+// Occurrences of a THROUGH reminder on consecutive days are exported as a single
+// multi-day VEVENT, everything else as one VEVENT per occurrence
+type icsEvent struct {
+	Event
+	Start Date
+	Days int
+	First Date // first day of the span even if it starts before the exported range, used for the UID
+}
+
+func collectICSEvents(start Date, days int, events map[string][]Event) []*icsEvent {
+	icsEvents := []*icsEvent{}
+	// open spans by source line, continued if the previous day had the same reminder
+	open := map[string]*icsEvent{}
+	d := start
+	for i := 0; i < days; i++ {
+		next := map[string]*icsEvent{}
+		for _, e := range events[d.NumericString()] {
+			if e.IsTimed() || e.Rep != 1 || e.Until == "" {
+				icsEvents = append(icsEvents, &icsEvent{e, d, 1, d})
+				continue
+			}
+			key := fmt.Sprintf("%s:%d:%s", e.Filename, e.Lineno, e.Message)
+			if span, ok := open[key]; ok {
+				span.Days++
+				next[key] = span
+				continue
+			}
+			span := &icsEvent{e, d, 1, spanStart(e, d, key, events)}
+			icsEvents = append(icsEvents, span)
+			next[key] = span
+		}
+		open = next
+		d.AddDay()
+	}
+	return icsEvents
+}
+
+// First day of the span e on day d is part of, spans cut off by --from are followed
+// back through the loaded days. Those start at the first of a month, a span reaching
+// it may start even earlier, then a trigger with a full date is its first day
+func spanStart(e Event, d Date, key string, events map[string][]Event) Date {
+	for {
+		prev := d
+		prev.SubtractDay()
+		found := false
+		for _, pe := range events[prev.NumericString()] {
+			if fmt.Sprintf("%s:%d:%s", pe.Filename, pe.Lineno, pe.Message) == key { found = true; break }
+		}
+		if !found { break }
+		d = prev
+	}
+	if d.Day == 1 && e.TrigYear > 0 && e.TrigMonth > 0 && e.TrigDay > 0 && !e.NonConstExpr {
+		if first, err := NewDate(e.TrigYear, e.TrigMonth, e.TrigDay); err == nil && dateBefore(first, d) { return first }
+	}
+	return d
+}
+
+func writeICS(w io.Writer, start Date, days int, events map[string][]Event, now time.Time) error {
+	iw := &icsWriter{w: w}
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//remindcal//remindcal//EN")
+	iw.line("CALSCALE:GREGORIAN")
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	uids := map[string]int{}
+	for _, e := range collectICSEvents(start, days, events) {
+		iw.line("BEGIN:VEVENT")
+		iw.line("UID:" + icsUID(e, uids))
+		iw.line("DTSTAMP:" + stamp)
+		if e.IsTimed() {
+			t := time.Date(e.Start.Year, time.Month(e.Start.Month), e.Start.Day, e.Time/60, e.Time%60, 0, 0, time.Local)
+			iw.line("DTSTART:" + t.UTC().Format("20060102T150405Z"))
+			duration := e.EventDuration
+			if duration < 0 { duration = e.Duration }
+			if duration > 0 {
+				iw.line("DTEND:" + t.Add(time.Duration(duration)*time.Minute).UTC().Format("20060102T150405Z"))
+			}
+		} else {
+			end := e.Start
+			for i := 0; i < e.Days; i++ { end.AddDay() }
+			iw.line("DTSTART;VALUE=DATE:" + icsDate(e.Start))
+			iw.line("DTEND;VALUE=DATE:" + icsDate(end))
+		}
+		iw.line("SUMMARY:" + icsEscape(e.Title()))
+		if len(e.Tags) > 0 {
+			categories := []string{}
+			for _, tag := range e.Tags { categories = append(categories, icsEscape(tag)) }
+			iw.line("CATEGORIES:" + strings.Join(categories, ","))
+		}
+		if e.Filename != "" {
+			iw.line("X-REMIND-FILE:" + icsEscape(e.Filename))
+			iw.line(fmt.Sprintf("X-REMIND-LINE:%d", e.Lineno))
+		}
+		iw.line("END:VEVENT")
+	}
+	iw.line("END:VCALENDAR")
+	return iw.err
+}
+
+func icsDate(d Date) string {
+	return fmt.Sprintf("%04d%02d%02d", d.Year, d.Month, d.Day)
+}
+
+// UIDs only depend on the content of the REM line ( body and trigger ) and the first
+// day of the occurrence so re-exports keep their UID, even with a different --from or
+// after lines above it were added or removed, and calendar apps update instead of duplicating
+func icsUID(e *icsEvent, seen map[string]int) string {
+	body := e.RawBody
+	if body == "" { body = e.Title() }
+	trigger := fmt.Sprintf("%d %d %d %s +%d -%d *%d %s", e.TrigDay, e.TrigMonth, e.TrigYear,
+		strings.Join(e.TrigWeekdays, " "), e.Delta, e.Back, e.Rep, e.Until)
+	base := fmt.Sprintf("%s|%s|%s|%s|%d", e.Filename, body, trigger, icsDate(e.First), e.Time)
+	seen[base]++
+	if seen[base] > 1 { base += fmt.Sprintf("|%d", seen[base]) }
+	return fmt.Sprintf("%x@remindcal", sha1.Sum([]byte(base)))
+}
+
+// TEXT escaping of RFC 5545 3.3.11
+func icsEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "").Replace(text)
+}
+
+// Writes content lines with CRLF and folds them at 75 octets
+// without splitting UTF-8 sequences
+type icsWriter struct {
+	w io.Writer
+	err error
+}
+
+func (iw *icsWriter) line(line string) {
+	if iw.err != nil { return }
+	folded := ""
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 { cut-- } // continuation byte
+		folded += line[:cut] + "\r\n "
+		line = line[cut:]
+		limit = 74 // leading space counts
+	}
+	_, iw.err = io.WriteString(iw.w, folded + line + "\r\n")
+}
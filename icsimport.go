@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// remindcal import-ics INVITE.ics --into FILE [--dry-run]
+// Converts the VEVENTs of an iCalendar file into REM lines and appends them to FILE
+// Everything that can not be represented exactly is reported on stderr
+func importICSCommand(args []string) int {
+	fs := flag.NewFlagSet("import-ics", flag.ContinueOnError)
+	into := fs.String("into", "", "reminder file the REM lines are appended to")
+	dryRun := fs.Bool("dry-run", false, "print the REM lines instead of appending them")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remindcal import-ics INVITE.ics --into FILE [--dry-run]\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil { return 2 }
+	if len(positional) != 1 || (*into == "" && !*dryRun) { fs.Usage(); return 2 }
+
+	f, err := os.Open(positional[0])
+	if err != nil { fmt.Fprintf(os.Stderr, "remindcal: %s\n", err); return 1 }
+	defer f.Close()
+	cal, err := parseICS(f)
+	if err != nil { fmt.Fprintf(os.Stderr, "remindcal: %s: %s\n", positional[0], err); return 1 }
+
+	existing := ""
+	if *into != "" {
+		content, err := os.ReadFile(*into)
+		if err != nil && !os.IsNotExist(err) { fmt.Fprintf(os.Stderr, "remindcal: %s\n", err); return 1 }
+		existing = string(content)
+	}
+
+	imported, skipped := 0, 0
+	lines := []string{}
+	for _, ev := range cal.All("VEVENT") {
+		summary := icsUnescape(ev.Value("SUMMARY"))
+		uidComment := "# import-ics UID:" + ev.Value("UID")
+		if ev.Value("UID") != "" && strings.Contains(existing, uidComment + "\n") {
+			fmt.Fprintf(os.Stderr, "%s: already imported, skipped\n", summary)
+			skipped++
+			continue
+		}
+		remLines, problems, err := convertVEvent(ev, cal)
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", summary, problem)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: not imported, %s\n", summary, err)
+			skipped++
+			continue
+		}
+		if ev.Value("UID") != "" { lines = append(lines, uidComment) }
+		lines = append(lines, remLines...)
+		imported++
+	}
+
+	if *dryRun {
+		for _, line := range lines { fmt.Println(line) }
+	} else if len(lines) > 0 {
+		if err := appendRemLine(*into, strings.Join(lines, "\n")); err != nil {
+			fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+			return 1
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d imported, %d skipped\n", imported, skipped)
+	return 0
+}
+
+///////////////// PARSING ////////////////////////////////
+
+type icsComponent struct {
+	Name string
+	Props []icsProperty
+	Children []*icsComponent
+}
+
+type icsProperty struct {
+	Name string
+	Params map[string]string
+	Value string
+}
+
+func (c *icsComponent) Prop(name string) *icsProperty {
+	for i := range c.Props {
+		if c.Props[i].Name == name { return &c.Props[i] }
+	}
+	return nil
+}
+
+func (c *icsComponent) Value(name string) string {
+	if p := c.Prop(name); p != nil { return p.Value }
+	return ""
+}
+
+// All nested components with name
+func (c *icsComponent) All(name string) []*icsComponent {
+	found := []*icsComponent{}
+	for _, child := range c.Children {
+		if child.Name == name { found = append(found, child) }
+		found = append(found, child.All(name)...)
+	}
+	return found
+}
+
+// Parses an iCalendar stream into a tree of components, the returned root
+// component contains the VCALENDAR(s)
+func parseICS(r io.Reader) (*icsComponent, error) {
+	root := &icsComponent{}
+	stack := []*icsComponent{root}
+
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// unfold continuation lines
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" { lines = append(lines, line) }
+	}
+	if err := scanner.Err(); err != nil { return nil, err }
+
+	for i, line := range lines {
+		prop, err := parseICSProperty(line)
+		if err != nil { return nil, fmt.Errorf("line %d: %w", i+1, err) }
+		current := stack[len(stack)-1]
+		switch prop.Name {
+		case "BEGIN":
+			child := &icsComponent{Name: strings.ToUpper(prop.Value)}
+			current.Children = append(current.Children, child)
+			stack = append(stack, child)
+		case "END":
+			if len(stack) < 2 || current.Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			current.Props = append(current.Props, prop)
+		}
+	}
+	if len(stack) != 1 { return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name) }
+	if len(root.All("VCALENDAR")) == 0 { return nil, fmt.Errorf("no VCALENDAR found") }
+	return root, nil
+}
+
+// NAME;PARAM=a;PARAM2="quoted:value":VALUE
+func parseICSProperty(line string) (icsProperty, error) {
+	prop := icsProperty{Params: map[string]string{}}
+	i := strings.IndexAny(line, ";:")
+	if i < 0 { return prop, fmt.Errorf("invalid content line %q", line) }
+	prop.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		line = line[i+1:]
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 { return prop, fmt.Errorf("invalid parameter in %q", line) }
+		name := strings.ToUpper(line[:eq])
+		line = line[eq+1:]
+		value := ""
+		if strings.HasPrefix(line, `"`) {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 { return prop, fmt.Errorf("unterminated quote in %q", line) }
+			value = line[1:end+1]
+			line = line[end+2:]
+			i = 0
+			if line == "" { return prop, fmt.Errorf("missing value") }
+		} else {
+			i = strings.IndexAny(line, ";:")
+			if i < 0 { return prop, fmt.Errorf("missing value") }
+			value = line[:i]
+		}
+		prop.Params[name] = value
+		if i >= len(line) { return prop, fmt.Errorf("missing value") }
+	}
+	prop.Value = line[i+1:]
+	return prop, nil
+}
+
+func icsUnescape(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+// Resolves TZID to a location, unknown ids fall back to the fixed standard offset
+// of the VTIMEZONE definition or local time ( reported as problem )
+func icsLocation(tzid string, cal *icsComponent, problems *[]string) *time.Location {
+	if tzid == "" { return time.Local }
+	if loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil { return loc }
+	for _, vtz := range cal.All("VTIMEZONE") {
+		if vtz.Value("TZID") != tzid { continue }
+		for _, std := range vtz.All("STANDARD") {
+			if offset, err := parseUTCOffset(std.Value("TZOFFSETTO")); err == nil {
+				*problems = append(*problems, fmt.Sprintf("unknown time zone %q, using its standard offset without daylight saving", tzid))
+				return time.FixedZone(tzid, offset)
+			}
+		}
+	}
+	*problems = append(*problems, fmt.Sprintf("unknown time zone %q, using local time", tzid))
+	return time.Local
+}
+
+// +0100 / -053000 into seconds east of UTC
+func parseUTCOffset(str string) (int, error) {
+	if len(str) != 5 && len(str) != 7 { return 0, fmt.Errorf("invalid offset %q", str) }
+	sign := 1
+	if str[0] == '-' { sign = -1 } else if str[0] != '+' { return 0, fmt.Errorf("invalid offset %q", str) }
+	h, err1 := strconv.Atoi(str[1:3])
+	m, err2 := strconv.Atoi(str[3:5])
+	s := 0
+	var err3 error
+	if len(str) == 7 { s, err3 = strconv.Atoi(str[5:7]) }
+	if err1 != nil || err2 != nil || err3 != nil { return 0, fmt.Errorf("invalid offset %q", str) }
+	return sign * (h*3600 + m*60 + s), nil
+}
+
+// Parses a DATE or DATE-TIME value, DATE-TIMEs are converted to local time
+func parseICSTime(value string, params map[string]string, cal *icsComponent, problems *[]string) (t time.Time, allDay bool, err error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+	} else {
+		t, err = time.ParseInLocation("20060102T150405", value, icsLocation(params["TZID"], cal, problems))
+	}
+	return t.In(time.Local), false, err
+}
+
+var icsDurationRegex = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// RFC 5545 DURATION e.g. PT1H30M, P1D, P2W
+func parseICSDuration(str string) (time.Duration, error) {
+	m := icsDurationRegex.FindStringSubmatch(str)
+	if m == nil || str == "P" { return 0, fmt.Errorf("invalid duration %q", str) }
+	n := func(s string) time.Duration { v, _ := strconv.Atoi(s); return time.Duration(v) }
+	d := n(m[2])*7*24*time.Hour + n(m[3])*24*time.Hour + n(m[4])*time.Hour + n(m[5])*time.Minute + n(m[6])*time.Second
+	if m[1] == "-" { d = -d }
+	return d, nil
+}
+
+///////////////// CONVERSION ////////////////////////////////
+
+var icsWeekdays = map[string]int{"SU": 0, "MO": 1, "TU": 2, "WE": 3, "TH": 4, "FR": 5, "SA": 6}
+
+type icsByDay struct {
+	N int // nth occurrence in month, 0 for every
+	Weekday int
+}
+
+type icsRule struct {
+	Freq string
+	Interval int
+	Count int
+	Until *Date
+	ByDay []icsByDay
+	ByMonthDay []int
+	ByMonth []int
+}
+
+var byDayRegex = regexp.MustCompile(`^([+-]?\d{1,2})?(SU|MO|TU|WE|TH|FR|SA)$`)
+
+func parseRRule(value string, cal *icsComponent, problems *[]string) (rule icsRule, err error) {
+	rule.Interval = 1
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err != nil || rule.Interval < 1 { return rule, fmt.Errorf("invalid INTERVAL %q", val) }
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err != nil || rule.Count < 1 { return rule, fmt.Errorf("invalid COUNT %q", val) }
+		case "UNTIL":
+			t, _, err := parseICSTime(val, map[string]string{}, cal, problems)
+			if err != nil { return rule, fmt.Errorf("invalid UNTIL %q", val) }
+			until, _ := NewDate(t.Year(), int(t.Month()), t.Day())
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				m := byDayRegex.FindStringSubmatch(strings.ToUpper(day))
+				if m == nil { return rule, fmt.Errorf("invalid BYDAY %q", day) }
+				n := 0
+				if m[1] != "" { n, _ = strconv.Atoi(m[1]) }
+				rule.ByDay = append(rule.ByDay, icsByDay{n, icsWeekdays[m[2]]})
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n < 1 || n > 31 { return rule, fmt.Errorf("BYMONTHDAY %q is not supported", day) }
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "WKST":
+		case "BYMONTH":
+			for _, month := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(month)
+				if err != nil || n < 1 || n > 12 { return rule, fmt.Errorf("invalid BYMONTH %q", month) }
+				rule.ByMonth = append(rule.ByMonth, n)
+			}
+		default:
+			return rule, fmt.Errorf("RRULE part %s is not supported", key)
+		}
+	}
+	switch rule.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rule, fmt.Errorf("RRULE FREQ=%s is not supported", rule.Freq)
+	}
+	return rule, nil
+}
+
+// Reports whether d is an occurrence of rule starting at start
+func (rule icsRule) matches(start Date, d Date) bool {
+	wd := Weekday(d.Year, time.Month(d.Month), d.Day)
+	months := (d.Year-start.Year)*12 + d.Month - start.Month
+	switch rule.Freq {
+	case "DAILY":
+		if daysBetween(start, d) % rule.Interval != 0 { return false }
+		if len(rule.ByDay) == 0 { return true }
+		for _, bd := range rule.ByDay {
+			if bd.Weekday == wd { return true }
+		}
+		return false
+	case "WEEKLY":
+		if (daysBetween(weekStart(start, time.Monday), weekStart(d, time.Monday))/7) % rule.Interval != 0 { return false }
+		if len(rule.ByDay) == 0 { return wd == Weekday(start.Year, time.Month(start.Month), start.Day) }
+		for _, bd := range rule.ByDay {
+			if bd.Weekday == wd { return true }
+		}
+		return false
+	case "MONTHLY":
+		if months % rule.Interval != 0 { return false }
+		if len(rule.ByMonthDay) > 0 {
+			for _, md := range rule.ByMonthDay {
+				if md == d.Day { return true }
+			}
+			return false
+		}
+		if len(rule.ByDay) > 0 {
+			for _, bd := range rule.ByDay {
+				if bd.Weekday != wd { continue }
+				if bd.N == 0 || (bd.N > 0 && (d.Day-1)/7+1 == bd.N) { return true }
+				if bd.N < 0 && (d.daysInMonth-d.Day)/7+1 == -bd.N { return true }
+			}
+			return false
+		}
+		return d.Day == start.Day
+	case "YEARLY":
+		return months % (12*rule.Interval) == 0 && d.Day == start.Day
+	}
+	return false
+}
+
+// Last occurrence for rules limited by COUNT
+func (rule icsRule) lastOccurrence(start Date) Date {
+	d, last := start, start
+	found := 0
+	for i := 0; i < 100*366 && found < rule.Count; i++ {
+		if rule.matches(start, d) { found++; last = d }
+		d.AddDay()
+	}
+	return last
+}
+
+// Trigger part of the REM line for rule, e.g. "Mon Wed FROM 2026-10-19" or "2026-10-19 *14"
+func (rule icsRule) remTrigger(start Date) (string, error) {
+	weekdayName := func(wd int) string { return time.Weekday(wd).String()[:3] }
+	// BYMONTH only makes sense if it repeats the month of DTSTART in yearly rules
+	if len(rule.ByMonth) > 0 && (rule.Freq != "YEARLY" || len(rule.ByMonth) > 1 || rule.ByMonth[0] != start.Month) {
+		return "", fmt.Errorf("BYMONTH is only supported for the month of DTSTART in yearly rules")
+	}
+	switch rule.Freq {
+	case "DAILY":
+		if len(rule.ByDay) == 0 { return fmt.Sprintf("%s *%d", remDate(start), rule.Interval), nil }
+		// e.g. every workday
+		if rule.Interval != 1 { return "", fmt.Errorf("daily rules with BYDAY and INTERVAL > 1 are not supported") }
+		days := []string{}
+		for _, bd := range rule.ByDay {
+			if bd.N != 0 { return "", fmt.Errorf("daily BYDAY %d is not supported", bd.N) }
+			days = append(days, weekdayName(bd.Weekday))
+		}
+		return strings.Join(days, " ") + " FROM " + remDate(start), nil
+	case "WEEKLY":
+		startWd := Weekday(start.Year, time.Month(start.Month), start.Day)
+		if len(rule.ByDay) == 0 || (len(rule.ByDay) == 1 && rule.ByDay[0].Weekday == startWd) {
+			return fmt.Sprintf("%s *%d", remDate(start), 7*rule.Interval), nil
+		}
+		if rule.Interval != 1 { return "", fmt.Errorf("weekly rules on several weekdays with INTERVAL > 1 are not supported") }
+		days := []string{}
+		for _, bd := range rule.ByDay { days = append(days, weekdayName(bd.Weekday)) }
+		return strings.Join(days, " ") + " FROM " + remDate(start), nil
+	case "MONTHLY":
+		if rule.Interval != 1 { return "", fmt.Errorf("monthly rules with INTERVAL > 1 are not supported") }
+		if len(rule.ByMonthDay) > 1 || len(rule.ByDay) > 1 { return "", fmt.Errorf("monthly rules on several days are not supported") }
+		if len(rule.ByDay) == 1 {
+			bd := rule.ByDay[0]
+			switch {
+			case bd.N >= 1 && bd.N <= 4:
+				return fmt.Sprintf("%s %d FROM %s", weekdayName(bd.Weekday), 1+7*(bd.N-1), remDate(start)), nil
+			case bd.N == -1:
+				// first weekday of next month, 7 days back
+				return fmt.Sprintf("%s 1 --7 FROM %s", weekdayName(bd.Weekday), remDate(start)), nil
+			}
+			return "", fmt.Errorf("monthly BYDAY %d is not supported", bd.N)
+		}
+		day := start.Day
+		if len(rule.ByMonthDay) == 1 { day = rule.ByMonthDay[0] }
+		return fmt.Sprintf("%d FROM %s", day, remDate(start)), nil
+	case "YEARLY":
+		if rule.Interval != 1 { return "", fmt.Errorf("yearly rules with INTERVAL > 1 are not supported") }
+		if len(rule.ByDay) > 0 || len(rule.ByMonthDay) > 0 { return "", fmt.Errorf("yearly rules with BYDAY or BYMONTHDAY are not supported") }
+		return fmt.Sprintf("%s %d FROM %s", time.Month(start.Month).String()[:3], start.Day, remDate(start)), nil
+	}
+	return "", fmt.Errorf("RRULE FREQ=%s is not supported", rule.Freq)
+}
+
+// TAG values may not contain spaces or commas
+var tagCleanRegex = regexp.MustCompile(`[\s,]+`)
+
+// Converts a VEVENT into REM lines, problems are approximations that were made
+// err is set if the event can not be represented at all
+func convertVEvent(ev *icsComponent, cal *icsComponent) (lines []string, problems []string, err error) {
+	if strings.EqualFold(ev.Value("STATUS"), "CANCELLED") { return nil, nil, fmt.Errorf("event is cancelled") }
+	dtstart := ev.Prop("DTSTART")
+	if dtstart == nil { return nil, nil, fmt.Errorf("DTSTART missing") }
+	start, allDay, err := parseICSTime(dtstart.Value, dtstart.Params, cal, &problems)
+	if err != nil { return nil, problems, fmt.Errorf("invalid DTSTART %q", dtstart.Value) }
+	startDate, _ := NewDate(start.Year(), int(start.Month()), start.Day())
+
+	// end is exclusive
+	var end time.Time
+	hasEnd := false
+	if dtend := ev.Prop("DTEND"); dtend != nil {
+		end, _, err = parseICSTime(dtend.Value, dtend.Params, cal, &problems)
+		if err != nil { return nil, problems, fmt.Errorf("invalid DTEND %q", dtend.Value) }
+		hasEnd = true
+	} else if duration := ev.Value("DURATION"); duration != "" {
+		d, err := parseICSDuration(duration)
+		if err != nil { return nil, problems, err }
+		end = start.Add(d)
+		hasEnd = true
+	}
+
+	message := icsUnescape(ev.Value("SUMMARY"))
+	if location := icsUnescape(ev.Value("LOCATION")); location != "" { message += " (" + location + ")" }
+	if message == "" { message = "(no title)" }
+	if ev.Prop("RECURRENCE-ID") != nil {
+		problems = append(problems, "changes a single occurrence of a recurring event, imported as separate reminder")
+	}
+	if ev.Prop("RDATE") != nil { problems = append(problems, "RDATE is not supported, extra dates ignored") }
+
+	trigger := remDate(startDate)
+	var rule *icsRule
+	if rrule := ev.Value("RRULE"); rrule != "" {
+		r, err := parseRRule(rrule, cal, &problems)
+		if err != nil { return nil, problems, err }
+		if r.Count > 0 {
+			last := r.lastOccurrence(startDate)
+			r.Until = &last
+		}
+		trigger, err = r.remTrigger(startDate)
+		if err != nil { return nil, problems, err }
+		rule = &r
+	}
+
+	parts := []string{"REM", trigger}
+	if rule != nil && rule.Until != nil { parts = append(parts, "UNTIL", remDate(*rule.Until)) }
+
+	if allDay {
+		if hasEnd {
+			days := int(end.Sub(start).Hours()/24 + 0.5)
+			if days > 1 && rule == nil {
+				last := startDate
+				for i := 1; i < days; i++ { last.AddDay() }
+				parts = append(parts, "THROUGH", remDate(last))
+			} else if days > 1 {
+				problems = append(problems, fmt.Sprintf("recurring event spans %d days, only the first day is imported", days))
+			}
+		}
+	} else {
+		parts = append(parts, "AT", fmt.Sprintf("%d:%02d", start.Hour(), start.Minute()))
+		if hasEnd && end.After(start) {
+			minutes := int(end.Sub(start).Minutes())
+			parts = append(parts, "DURATION", fmt.Sprintf("%d:%02d", minutes/60, minutes%60))
+		}
+	}
+
+	for _, category := range strings.Split(ev.Value("CATEGORIES"), ",") {
+		tag := tagCleanRegex.ReplaceAllString(strings.TrimSpace(icsUnescape(category)), "_")
+		if tag != "" { parts = append(parts, "TAG", tag) }
+	}
+
+	// excluded dates are omitted in a private omit context
+	exdates := []string{}
+	for _, prop := range ev.Props {
+		if prop.Name != "EXDATE" { continue }
+		for _, value := range strings.Split(prop.Value, ",") {
+			t, _, err := parseICSTime(value, prop.Params, cal, &problems)
+			if err != nil { problems = append(problems, fmt.Sprintf("invalid EXDATE %q ignored", value)); continue }
+			exdates = append(exdates, t.Format("2006-01-02"))
+		}
+	}
+	if len(exdates) > 0 {
+		if rule == nil {
+			problems = append(problems, "EXDATE without RRULE ignored")
+			exdates = nil
+		} else {
+			parts = append(parts, "SKIP")
+		}
+	}
+
+	parts = append(parts, "MSG", escapeRemMessage(message))
+	remLine := strings.Join(parts, " ")
+
+	if len(exdates) == 0 { return []string{remLine}, problems, nil }
+	lines = []string{"PUSH-OMIT-CONTEXT", "CLEAR-OMIT-CONTEXT"}
+	for _, exdate := range exdates { lines = append(lines, "OMIT " + exdate) }
+	lines = append(lines, remLine, "POP-OMIT-CONTEXT")
+	return lines, problems, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertVEventDaily(t *testing.T) {
+	tests := []struct {
+		rrule string
+		want string // empty if the rule is refused
+	}{
+		{"FREQ=DAILY", "REM 2026-10-19 *1 MSG Standup"},
+		{"FREQ=DAILY;INTERVAL=3", "REM 2026-10-19 *3 MSG Standup"},
+		{"FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", "REM Mon Tue Wed Thu Fri FROM 2026-10-19 MSG Standup"},
+		// 5 workdays from a monday end on friday, the weekend does not count
+		{"FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=6", "REM Mon Tue Wed Thu Fri FROM 2026-10-19 UNTIL 2026-10-26 MSG Standup"},
+		{"FREQ=DAILY;COUNT=6", "REM 2026-10-19 *1 UNTIL 2026-10-24 MSG Standup"},
+		{"FREQ=DAILY;INTERVAL=2;BYDAY=MO,WE", ""},
+		{"FREQ=DAILY;BYDAY=1MO", ""},
+	}
+	for _, test := range tests {
+		cal, err := parseICS(strings.NewReader(strings.Join([]string{
+			"BEGIN:VCALENDAR", "BEGIN:VEVENT", "DTSTART;VALUE=DATE:20261019",
+			"RRULE:" + test.rrule, "SUMMARY:Standup", "END:VEVENT", "END:VCALENDAR", "",
+		}, "\r\n")))
+		if err != nil { t.Fatal(err) }
+		events := cal.All("VEVENT")
+		if len(events) != 1 { t.Fatalf("parsed %d events, want 1", len(events)) }
+		lines, _, err := convertVEvent(events[0], cal)
+		if test.want == "" {
+			if err == nil { t.Errorf("%s converted to %q, want an error", test.rrule, lines) }
+			continue
+		}
+		if err != nil { t.Errorf("%s: %s", test.rrule, err); continue }
+		if len(lines) != 1 || lines[0] != test.want { t.Errorf("%s converted to %q, want %q", test.rrule, lines, test.want) }
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Named commands the keys are bound to
+// Label is shown in the status line, Description in the help overlay
+type Command struct {
+	Name string
+	Label string
+	Description string
+	Repeatable bool // a count prefix e.g. "3j" runs the command count times
+}
+
+var commands = []Command{
+	{"quit", "Quit", "Quit remindcal", false},
+	{"help", "Help", "Show all commands and their keys", false},
+	{"next-window", "ChgWin", "Switch to the next window", false},
+	{"edit", "Edit", "Open the selected event ( or the first problem ) in the editor", false},
+	{"add", "Add", "Add a reminder on the selected day", false},
+	{"delete", "Delete", "Delete the selected reminder", false},
+	{"move", "Move", "Move the selected reminder to another date", false},
+	{"week-view", "Week", "Toggle between the events list and the week view", false},
+	{"day-view", "Day", "Toggle between the events list and the timeline of the selected day", false},
+	{"today-window", "TodayWin", "Show or hide the window with today's reminders", false},
+	{"year-view", "Year", "Toggle the year overview", false},
+	{"open-day", "Open", "Show the selected day of the year overview in the month view", false},
+	{"search", "Search", "Search messages, tags and files", false},
+	{"next-hit", "Next", "Jump to the next search hit", true},
+	{"prev-hit", "Prev", "Jump to the previous search hit", true},
+	{"close-search", "Close", "Close the search results", false},
+	{"tag-filter", "Tags", "Show or hide events by tag", false},
+	{"left", "Left", "Previous day", true},
+	{"down", "Down", "Next week / next event / scroll down", true},
+	{"up", "Up", "Previous week / previous event / scroll up", true},
+	{"right", "Right", "Next day", true},
+	{"next-day", "+Day", "Next day in any window", true},
+	{"prev-day", "-Day", "Previous day in any window", true},
+	{"next-week", "+Week", "Next week in any window", true},
+	{"prev-week", "-Week", "Previous week in any window", true},
+	{"next-month", "+Month", "Next month", true},
+	{"prev-month", "-Month", "Previous month", true},
+	{"today", "Today", "Jump to today", false},
+	{"goto-date", "Go to", "Go to a date e.g. 2027-03-01, March 2027, +3w, next friday", false},
+}
+
+var defaultKeys = map[string][]string{
+	"quit": {"q"},
+	"help": {"?"},
+	"next-window": {"<Tab>"},
+	"edit": {"e"},
+	"add": {"a"},
+	"delete": {"D"},
+	"move": {"m"},
+	"week-view": {"w"},
+	"day-view": {"d"},
+	"today-window": {"R"},
+	"year-view": {"y"},
+	"open-day": {"<Enter>"},
+	"search": {"/"},
+	"next-hit": {"n"},
+	"prev-hit": {"N"},
+	"close-search": {"<Esc>"},
+	"tag-filter": {"t"},
+	"left": {"h", "<Left>"},
+	"down": {"j", "<Down>"},
+	"up": {"k", "<Up>"},
+	"right": {"l", "<Right>"},
+	"next-month": {"J", "<PageDown>"},
+	"prev-month": {"K", "<PageUp>"},
+	"today": {"T", "<Home>"},
+	"goto-date": {"g"},
+}
+
+// Commands listed in the status line
+var statusCommands = []string{
+	"quit", "help", "next-window", "edit", "add", "delete", "move", "week-view", "day-view", "year-view", "search", "tag-filter", "goto-date",
+	"left", "down", "up", "right",
+}
+
+// Keys written as <Name> in sequences, the name is also used to display them
+var namedKeys = []struct {
+	name string
+	key int
+}{
+	{"TAB", 9},
+	{"ESC", 27},
+	{"Enter", '\n'},
+	{"Space", ' '},
+	{"Left", KEY_LEFT},
+	{"Right", KEY_RIGHT},
+	{"Up", KEY_UP},
+	{"Down", KEY_DOWN},
+	{"PageDown", KEY_NPAGE},
+	{"PageUp", KEY_PPAGE},
+	{"Home", KEY_HOME},
+	{"End", KEY_END},
+	{"Del", KEY_DC},
+	{"Backspace", KEY_BACKSPACE},
+}
+
+// pending keys are dropped ( or run if they form a complete binding ) after this long
+const keySequenceTimeout = time.Second
+
+type binding struct {
+	keys []int
+	command string
+}
+
+// Maps key sequences to commands and collects multi key sequences and counts
+type Keymap struct {
+	bindings []binding
+	pending []int
+	count int
+	lastKey time.Time
+}
+
+func findCommand(name string) (Command, bool) {
+	for _, command := range commands {
+		if command.Name == name { return command, true }
+	}
+	return Command{}, false
+}
+
+// Builds the keymap from the default keys, commands in overrides replace their defaults
+func NewKeymap(overrides map[string][]string) (*Keymap, error) {
+	keys := map[string][]string{}
+	for name, sequences := range defaultKeys { keys[name] = sequences }
+	for name, sequences := range overrides {
+		if _, ok := findCommand(name); !ok { return nil, fmt.Errorf("keys: unknown command %q", name) }
+		keys[name] = sequences
+	}
+
+	k := &Keymap{}
+	for _, command := range commands {
+		for _, sequence := range keys[command.Name] {
+			parsed, err := parseKeySequence(sequence)
+			if err != nil { return nil, fmt.Errorf("keys.%s: %w", command.Name, err) }
+			for _, b := range k.bindings {
+				if equalKeys(b.keys, parsed) {
+					return nil, fmt.Errorf("keys.%s: %q is already bound to %s", command.Name, sequence, b.command)
+				}
+			}
+			k.bindings = append(k.bindings, binding{parsed, command.Name})
+		}
+	}
+	return k, nil
+}
+
+// "gg", "<C-r>", "<Tab>" or "g<Down>"
+func parseKeySequence(str string) ([]int, error) {
+	keys := []int{}
+	for len(str) > 0 {
+		if str[0] == '<' {
+			end := strings.IndexByte(str, '>')
+			if end < 0 { return nil, fmt.Errorf("missing > in %q", str) }
+			key, err := parseNamedKey(str[1:end])
+			if err != nil { return nil, err }
+			keys = append(keys, key)
+			str = str[end+1:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(str)
+		if r > 255 { return nil, fmt.Errorf("unsupported key %q", r) }
+		keys = append(keys, int(r))
+		str = str[size:]
+	}
+	if len(keys) == 0 { return nil, fmt.Errorf("empty key sequence") }
+	return keys, nil
+}
+
+func parseNamedKey(name string) (int, error) {
+	for _, named := range namedKeys {
+		if strings.EqualFold(named.name, name) { return named.key, nil }
+	}
+	if strings.EqualFold(name, "Tab") { return 9, nil }
+	if strings.EqualFold(name, "Esc") { return 27, nil }
+	if strings.EqualFold(name, "lt") { return '<', nil }
+	// <C-x> control keys
+	if len(name) == 3 && strings.EqualFold(name[:2], "C-") {
+		c := name[2] | 0x20 // lower case
+		if c >= 'a' && c <= 'z' { return int(c - 'a' + 1), nil }
+	}
+	return 0, fmt.Errorf("unknown key <%s>", name)
+}
+
+func keyName(key int) string {
+	for _, named := range namedKeys {
+		if named.key == key { return named.name }
+	}
+	if key >= 1 && key <= 26 { return "C-" + string(rune('a' + key - 1)) }
+	if key > 32 && key < 256 { return string(rune(key)) }
+	return fmt.Sprintf("#%d", key)
+}
+
+func sequenceName(keys []int) string {
+	names := []string{}
+	for _, key := range keys { names = append(names, keyName(key)) }
+	return strings.Join(names, "")
+}
+
+func equalKeys(a []int, b []int) bool {
+	if len(a) != len(b) { return false }
+	for i := range a {
+		if a[i] != b[i] { return false }
+	}
+	return true
+}
+
+// Handles one key of Getch, returns the command once a binding is complete
+// count is the numeric prefix ( 1 without ) and always 1 for commands that are not repeatable
+// unbound is set to the keys if they do not start any binding
+func (k *Keymap) Feed(c int) (command string, count int, unbound string) {
+	if c == KEY_RESIZE { return "", 0, "" }
+	if c == '\r' || c == KEY_ENTER { c = '\n' }
+	if c == -1 {
+		// timeout, run a complete binding that is also the prefix of a longer one e.g. "g" and "gg"
+		if len(k.pending) == 0 || time.Since(k.lastKey) < keySequenceTimeout { return "", 0, "" }
+		command = k.exact(k.pending)
+		count = k.takeCount(command)
+		k.pending = nil
+		return command, count, ""
+	}
+	k.lastKey = time.Now()
+
+	if c == 27 && (len(k.pending) > 0 || k.count > 0) {
+		// ESC cancels a started sequence
+		k.pending = nil
+		k.count = 0
+		return "", 0, ""
+	}
+	if len(k.pending) == 0 && c >= '0' && c <= '9' && (c != '0' || k.count > 0) && !k.isPrefix([]int{c}) {
+		k.count = k.count*10 + c - '0'
+		return "", 0, ""
+	}
+
+	k.pending = append(k.pending, c)
+	command = k.exact(k.pending)
+	if k.isLongerPrefix(k.pending) { return "", 0, "" } // wait for the next key
+	if command == "" { unbound = sequenceName(k.pending) }
+	count = k.takeCount(command)
+	k.pending = nil
+	return command, count, unbound
+}
+
+// Keys typed so far e.g. "3g", shown in the status line
+func (k *Keymap) Pending() string {
+	str := sequenceName(k.pending)
+	if k.count > 0 { str = fmt.Sprint(k.count) + str }
+	return str
+}
+
+func (k *Keymap) takeCount(command string) int {
+	count := k.count
+	k.count = 0
+	if count < 1 { return 1 }
+	if c, ok := findCommand(command); !ok || !c.Repeatable { return 1 }
+	return count
+}
+
+func (k *Keymap) exact(keys []int) string {
+	for _, b := range k.bindings {
+		if equalKeys(b.keys, keys) { return b.command }
+	}
+	return ""
+}
+
+func (k *Keymap) isPrefix(keys []int) bool {
+	for _, b := range k.bindings {
+		if len(b.keys) >= len(keys) && equalKeys(b.keys[:len(keys)], keys) { return true }
+	}
+	return false
+}
+
+func (k *Keymap) isLongerPrefix(keys []int) bool {
+	for _, b := range k.bindings {
+		if len(b.keys) > len(keys) && equalKeys(b.keys[:len(keys)], keys) { return true }
+	}
+	return false
+}
+
+// Key sequences bound to command, sorted so single keys come first
+func (k *Keymap) Keys(command string) []string {
+	keys := []string{}
+	for _, b := range k.bindings {
+		if b.command == command { keys = append(keys, sequenceName(b.keys)) }
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return len(keys[i]) < len(keys[j]) })
+	return keys
+}
+
+// Status line listing e.g. "q:Quit  TAB:ChgWin  e:Edit", unbound commands are left out
+func (k *Keymap) Help(names []string) string {
+	entries := []string{}
+	for _, name := range names {
+		command, _ := findCommand(name)
+		keys := k.Keys(name)
+		if len(keys) == 0 { continue }
+		entries = append(entries, keys[0] + ":" + command.Label)
+	}
+	return strings.Join(entries, "  ")
+}
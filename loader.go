@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Runs remind in the background and caches the resulting events per month
+// so month changes never block the ui. Requested months are loaded lazily,
+// the ui polls Window() on every loop iteration ( see halfdelay )
+type EventLoader struct {
+	filename string
+	mu sync.Mutex
+	months map[string]*monthEvents
+	gen int     // increased on Invalidate, entries of older generations are reloaded
+	version int // increased whenever a month finished loading
+	maxMonths int
+	lastDuration time.Duration
+}
+
+type monthEvents struct {
+	year int
+	month int
+	events []Event
+	err error
+	good bool // events come from an error free remind run
+	gen int
+	loading bool
+}
+
+func NewEventLoader(filename string) *EventLoader {
+	return &EventLoader{
+		filename: filename,
+		months: map[string]*monthEvents{},
+		maxMonths: 24,
+	}
+}
+
+func monthKey(year int, month int) string {
+	return fmt.Sprintf("%d-%d", year, month)
+}
+
+// Returns events of nrOfMonth months starting at year, month
+// Months that are not cached yet are loaded in the background, in that case
+// loading is true and the months are missing in events until they are done.
+// err is the first remind error of the requested months
+func (l *EventLoader) Window(year int, month int, nrOfMonth int) (events map[string][]Event, err error, loading bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events = map[string][]Event{}
+	y, m := year, month
+	for i := 0; i < nrOfMonth; i++ {
+		me := l.load(y, m)
+		for _, e := range me.events {
+			addEvent(e, events)
+		}
+		if err == nil { err = me.err }
+		if me.loading { loading = true }
+		y, m = AddMonth(y, m)
+	}
+	sortEvents(events)
+	l.evict(year, month)
+	return
+}
+
+// Loads the months before and after the window so J/K feel instant
+func (l *EventLoader) Prefetch(year int, month int, nrOfMonth int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	y, m := SubtractMonth(year, month)
+	l.load(y, m)
+	y, m = year, month
+	for i := 0; i < nrOfMonth; i++ { y, m = AddMonth(y, m) }
+	l.load(y, m)
+}
+
+// Marks all cached months as outdated e.g. after the reminder files changed
+// Outdated events are still returned until the reload finished
+func (l *EventLoader) Invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gen++
+	l.version++
+}
+
+// Increases with every Invalidate, used to reload data derived from the reminder files
+func (l *EventLoader) Generation() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.gen
+}
+
+func (l *EventLoader) Version() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.version
+}
+
+// Duration of the last remind call
+func (l *EventLoader) LastDuration() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastDuration
+}
+
+// starts loading year, month if necessary, l.mu must be held
+func (l *EventLoader) load(year int, month int) *monthEvents {
+	key := monthKey(year, month)
+	me, ok := l.months[key]
+	if !ok {
+		me = &monthEvents{year: year, month: month, gen: -1}
+		l.months[key] = me
+	}
+	if me.loading || me.gen == l.gen { return me }
+
+	me.loading = true
+	gen := l.gen
+	go func() {
+		start := time.Now()
+		eventsArr, err := getEvents(l.filename, year, month, 1)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.lastDuration = time.Now().Sub(start)
+		me.loading = false
+		me.gen = gen
+		me.err = err
+		// keep the last good events if remind failed
+		if err == nil || (eventsArr != nil && !me.good) {
+			me.events = eventsArr
+			me.good = err == nil
+		}
+		l.version++
+	}()
+	return me
+}
+
+// drops months far away from year, month, l.mu must be held
+func (l *EventLoader) evict(year int, month int) {
+	if len(l.months) <= l.maxMonths { return }
+	center := year*12 + month
+	for key, me := range l.months {
+		dist := me.year*12 + me.month - center
+		if dist < 0 { dist = -dist }
+		if !me.loading && dist > l.maxMonths/2 {
+			delete(l.months, key)
+		}
+	}
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"time"
 	"strconv"
+	"strings"
+	"regexp"
+	"sort"
 	"math"
 	"os"
 	"os/signal"
+	"errors"
+	"path/filepath"
 	"syscall"
 )
 
@@ -139,22 +145,143 @@ type Event struct {
 	Message string
 	Filename string
 	Lineno int
+	LinenoStart int // first line of a continued REM line, equals Lineno otherwise
+
+	// time information, Time and Duration are in minutes ( -1 if not set )
+	Time int
+	Duration int
+	EventStart time.Time // zero if reminder is untimed
+	EventDuration int
+
+	Tags []string
+	Passthru string // SPECIAL type e.g. COLOR, SHADE, MOON ( empty for normal reminders )
+	Color *RGB      // SPECIAL COLOR, nil for other reminders
+	SpanDay int     // day of a multi-day span e.g. THROUGH starting at 1, see span.go
+	SpanDays int    // length of the span, 0 if the event is not part of one
+	Priority int
+	RawBody string
+
+	// trigger fields as written in the REM line ( 0 / empty if omitted )
+	TrigDay int
+	TrigMonth int
+	TrigYear int
+	TrigWeekdays []string
+	Delta int // +N advance warning, negative for ++N
+	Back int  // -N
+	Rep int   // *N
+	Until string
+	NonConstExpr bool // trigger depends on an expression e.g. [easterdate(...)]
 }
 func NewEvent(year int, month int, day int, message string) (e Event, err error) {
 	e.Date, err = NewDate(year, month, day)
 	e.Message = message
+	e.Time = -1
+	e.Duration = -1
+	e.EventDuration = -1
 	return
 }
+func (e *Event) IsTimed() bool {
+	return e.Time >= 0
+}
+// Message without the time remind prefixes timed reminders with e.g. "9:30am Dentist"
+func (e *Event) Title() string {
+	if !e.IsTimed() { return e.Message }
+	return timePrefixRegex.ReplaceAllString(e.Message, "")
+}
+var timePrefixRegex = regexp.MustCompile(`^\d{1,2}([:.]\d{2})?\s?([aApP][mM])?\s+`)
+
+func (e *Event) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) { return true }
+	}
+	return false
+}
+
+const usage = `Usage: remindcal [FILE] [--date DATE] [--config PATH] [--set KEY=VALUE] [--week-start DAY] ...
+       remindcal agenda FILE [--from DATE] [--days N] [--format text|json|tsv]
+       remindcal export-ics FILE [--from DATE] [--to DATE] [-o OUT]
+       remindcal import-ics INVITE.ics --into FILE [--dry-run]
+`
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Printf("Usage: remindcal filename\n")
+	switch argOrEmpty(os.Args, 1) {
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		os.Exit(0)
+	}
+
+	cfg := DefaultConfig()
+	subcommands := map[string]func([]string) int{
+		"agenda": agendaCommand,
+		"export-ics": exportICSCommand,
+		"import-ics": importICSCommand,
+	}
+	if command, ok := subcommands[argOrEmpty(os.Args, 1)]; ok {
+		if err := loadConfigFile(&cfg, defaultConfigPath(), false); err != nil {
+			fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+			os.Exit(2)
+		}
+		cfg.Apply()
+		os.Exit(command(os.Args[2:]))
+	}
+
+	// settings given on the command line are applied after the config file
+	overrides := []string{}
+	fs := flag.NewFlagSet("remindcal", flag.ContinueOnError)
+	configPath := fs.String("config", "", "config file ( default " + defaultConfigPath() + " )")
+	fs.Var(settingFlag{"", &overrides, false}, "set", "override a config setting e.g. colors.today=green ( repeatable )")
+	fs.Var(settingFlag{"week_start", &overrides, false}, "week-start", "first day of the week: sunday, monday or saturday")
+	fs.Var(settingFlag{"remind.command", &overrides, false}, "remind", "remind binary")
+	fs.Var(settingFlag{"editor", &overrides, false}, "editor", "editor command, {file} and {line} are replaced")
+	fs.Var(settingFlag{"time_format", &overrides, false}, "time-format", "clock shown in the ui: 24h or 12h")
+	fs.Var(settingFlag{"panes.today", &overrides, true}, "today", "show the today window")
+	fs.Var(settingFlag{"panes.week", &overrides, true}, "week", "start in the week view")
+	fs.Var(settingFlag{"panes.debug", &overrides, true}, "debug", "show remind timings")
+	dateFlag := fs.String("date", "", "start on this date e.g. 2027-03-01, \"March 2027\" or +3w")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, os.Args[1:])
+	if err != nil { os.Exit(2) }
+	if len(positional) > 1 { fs.Usage(); os.Exit(2) }
+
+	path, required := *configPath, true
+	if path == "" { path, required = defaultConfigPath(), false }
+	err = loadConfigFile(&cfg, path, required)
+	for _, assignment := range overrides {
+		if err != nil { break }
+		err = cfg.SetString(assignment)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
+		os.Exit(2)
+	}
+
+	filename := cfg.Path
+	if len(positional) == 1 { filename = positional[0] }
+	if filename == "" { fs.Usage(); os.Exit(2) }
+
+	cfg.Apply()
+	t := time.Now()
+	today, err := NewDate(t.Year(), int(t.Month()), t.Day())
+	if err != nil { fmt.Fprintf(os.Stderr, "remindcal: %s\n", err); os.Exit(1) }
+	start := today
+	if *dateFlag != "" {
+		start, err = parseGotoDate(*dateFlag, today, today)
+		if err != nil { fmt.Fprintf(os.Stderr, "remindcal: %s\n", err); os.Exit(2) }
+	}
+
+	err = DrawingLoop(filename, cfg, start)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "remindcal: %s\n", err)
 		os.Exit(1)
 	}
-	filename := os.Args[1]
-	todayWinEnabled := false
-	debug := false
-	DrawingLoop(filename, todayWinEnabled, debug)
+}
+
+func argOrEmpty(args []string, i int) string {
+	if i >= len(args) { return "" }
+	return args[i]
 }
 
 // changes events in place
@@ -167,62 +294,109 @@ func addEvent(e Event, events map[string][]Event) {
 	}
 }
 
+// Orders the events of every day like remind -g: untimed events first,
+// timed events by start time, events at the same time by priority
+func sortEvents(events map[string][]Event) {
+	for _, dayEvents := range events {
+		sort.SliceStable(dayEvents, func(i, j int) bool { return eventBefore(dayEvents[i], dayEvents[j]) })
+	}
+}
+
+func eventBefore(a Event, b Event) bool {
+	if a.IsTimed() != b.IsTimed() { return !a.IsTimed() }
+	if a.Time != b.Time { return a.Time < b.Time }
+	return a.Priority < b.Priority
+}
+
 const EVENTS_WIN = 0
 const CALENDAR_WIN = 1
 const TODAY_WIN = 2
 
-func DrawingLoop(filename string, todayWinEnabled bool, debug bool) {
+func DrawingLoop(filename string, cfg Config, start Date) error {
+	var todayWinEnabled = cfg.TodayPane
+	var debug = cfg.Debug
+	keymap, err := NewKeymap(cfg.Keys)
+	if err != nil { return err }
 	var events = map[string][]Event{}
-	var todayMessageLines = []string{}
+	var todayReminders = TodayReminders{}
+	var todayLoads = make(chan todayLoad, 1) // getToday runs remind in the background
+	var todayLoading = false
 	var statusMessage = ""
+	// last remind failure, while set the error window is shown and
+	// the last good events stay on screen
+	var remindErr error
+	var loader = NewEventLoader(filename)
+	var loaderVersion = -1
+	var loading = false
+	var watcher = NewFileWatcher(filename)
+	defer watcher.Close()
+	var errorLines = []string{}
 
 	t := time.Now()
-	var d Date
-	d, err := NewDate(t.Year(), int(t.Month()), t.Day())
-	if err != nil { panic(err) }
-	var today = d
+	var today Date
+	today, err = NewDate(t.Year(), int(t.Month()), t.Day())
+	if err != nil { return err }
+	todayReminders.Date = today
+	var d = start
 
 	var activeWin = CALENDAR_WIN // default window
 	var selectedEvent = -1
+	var weekView = cfg.WeekPane // week grid instead of the events list
+	var dayView = false // timeline of the selected day instead of the events list
+	var yearView = false // full screen year overview instead of all other windows
+	var tagFilter = TagFilter{}
+	var specials = map[string]DaySpecial{}
+	var pendingSelection *Event // selected once its day is loaded e.g. after a search jump
+
+	var search = Search{Current: -1}
+	var searchIndex = SearchIndex{}
+	var searchVersion = -1
+	var searchLoading = false
+	var searchYear = d.Year // year the search started in, jumping around does not move the window
 	var yOffsetTodayWin = 0
 
 	var updateSize = true
 	var updateEvents = true
 	var updateToday = true
+	var todayFits = true // the today window needs three rows below one month
 	
-	var ys = YearStructure{}
 	var wPadding = 0
 	var prevYear = 0
 	var prevMonth = 0
+	var calMonths = 1 // months stacked in the side panel, as many as fit
+	var calWins = []*Window{}
+	var panelStart Date // first month of the side panel
 
 	Setlocale(LC_ALL, "") // unicode support
 	stdscr, err := Initscr()
-	if err != nil { panic(err) }
+	if err != nil { return err }
 	defer Endwin()
 	rows, cols := stdscr.Getmaxyx()
+	var eventsHeight = rows-2
+	var errorHeight = 0
 
 	// size and pos of windows is set on updateSize
 	eventsWin, err := Newwin(0, 0, 0, 0)
-	if err != nil { panic(err) }
-	calWidgetWin, err := Newwin(0, 0, 0, 0)
-	if err != nil { panic(err) }
+	if err != nil { return err }
 	todayWin, err := Newwin(0, 0, 0, 0)
-	if err != nil { panic(err) }
+	if err != nil { return err }
 	statusWin, err := Newwin(0, 0, 0, 0)
-	if err != nil { panic(err) }
+	if err != nil { return err }
+	errorWin, err := Newwin(0, 0, 0, 0)
+	if err != nil { return err }
+	yearWin, err := Newwin(0, 0, 0, 0)
+	if err != nil { return err }
 
 	Raw()
 	Noecho()
 	Keypad(stdscr, true)
 	Curs_set(0)
 	Halfdelay(4)
+	Set_escdelay(25)
 
 	Start_color()
 	Use_default_colors()
-	Init_pair(1, COLOR_RED, -1);
-	Init_pair(2, COLOR_CYAN, -1);
-	Init_pair(3, COLOR_YELLOW, -1);
-	Init_pair(5, COLOR_WHITE, COLOR_BLUE);
+	cfg.InitColors()
 
 	// Signal Handling for Terminal Resize Detection
 	c := make(chan os.Signal, 1)
@@ -232,77 +406,359 @@ func DrawingLoop(filename string, todayWinEnabled bool, debug bool) {
 	for {
 		if updateSize {
 			Endwin()
+			Clear() // removes leftovers of dialogs
 			Refresh()
 			rows, cols = stdscr.Getmaxyx()
 
-			eventsWin.Resize(rows-2, cols-34-wPadding)
-			calWidgetWin.Resize(10, 34)
-			calWidgetWin.Mv(0, cols-34)
-			todayWin.Resize(rows-10-2, 34)
-			todayWin.Mv(10, cols-34)
+			// error window takes up to a third of the events column
+			errorHeight = 0
+			if remindErr != nil {
+				errorHeight = len(errorLines) + 2
+				if errorHeight > (rows-2)/3 { errorHeight = (rows-2)/3 }
+				if errorHeight < 3 { errorHeight = 3 }
+				// the events window keeps at least three rows, otherwise the error only goes to the status line
+				if rows-2-errorHeight < 3 { errorHeight = 0 }
+			}
+			eventsHeight = rows-2-errorHeight
+
+			eventsWin.Resize(eventsHeight, cols-34-wPadding)
+			if errorHeight > 0 {
+				errorWin.Resize(errorHeight, cols-34-wPadding)
+				errorWin.Mv(eventsHeight, 0)
+			}
+			// side panel, the today window keeps at least the height of one month
+			todayFits = rows-2-10 >= 3
+			if !todayFits && activeWin == TODAY_WIN { activeWin = CALENDAR_WIN }
+			calMonths = (rows-2) / 10
+			if todayWinEnabled && todayFits { calMonths = (rows-2-10) / 10 }
+			if calMonths < 1 { calMonths = 1 }
+			for len(calWins) < calMonths {
+				win, err := Newwin(0, 0, 0, 0)
+				if err != nil { return err }
+				calWins = append(calWins, win)
+			}
+			for len(calWins) > calMonths {
+				calWins[len(calWins)-1].Delete()
+				calWins = calWins[:len(calWins)-1]
+			}
+			for i, win := range calWins {
+				win.Resize(10, 34)
+				win.Mv(10*i, cols-34)
+			}
+			todayWin.Resize(rows-10*calMonths-2, 34)
+			todayWin.Mv(10*calMonths, cols-34)
+			updateEvents = true // the panel may show more months
 			statusWin.Resize(2, cols)
 			statusWin.Mv(rows-2, 0)
+			yearWin.Resize(rows-2, cols)
 
-			updateToday = true // to update todayMessageLines ( based on new rows/cols )
 			updateSize = false
 		}
+		if t := time.Now(); t.Day() != today.Day || int(t.Month()) != today.Month || t.Year() != today.Year {
+			// past midnight
+			today, _ = NewDate(t.Year(), int(t.Month()), t.Day())
+			updateToday = true
+		}
+		panelStart = scrollPanel(panelStart, d, calMonths)
 		if d.Month != prevMonth || d.Year != prevYear {
-			if d.Year != prevYear {
-				ys = GenerateYearStructure(d.Year)	
-				prevYear = d.Year
-			}
-			if d.Month != prevMonth { prevMonth = d.Month }
+			prevYear = d.Year
+			prevMonth = d.Month
 			updateEvents = true
 		}
+		select {
+		case load := <-todayLoads:
+			todayLoading = false
+			if load.err == nil {
+				todayReminders = load.reminders
+			} else if remindErr == nil {
+				statusMessage = "Today: " + load.err.Error()
+			}
+			if load.reminders.Date != today { updateToday = true } // started before midnight
+		default:
+		}
+		select {
+		case changed := <-watcher.Changes():
+			loader.Invalidate()
+			updateToday = true
+			if statusMessage == "" { statusMessage = "Reloaded, changed: " + filepath.Base(changed) }
+		default:
+		}
+		if loader.Version() != loaderVersion { updateEvents = true }
 		if updateEvents {
-			start := time.Now()
-
-			// Clear events and populate via remind command
-			// This takes the longest and could freeze ui but generally only takes 0.03s
-			events = map[string][]Event{}
-			year, month := SubtractMonth(d.Year, d.Month)
-			eventsArr := getEvents(filename, year, month, 3)
-			for _, e := range eventsArr {
-				addEvent(e, events)
+			// Events are loaded by remind in the background ( see loader.go )
+			// months that are not loaded yet are filled in on a later iteration
+			loaderVersion = loader.Version()
+			// the side panel and the days of the neighbouring months it shows
+			year, month := SubtractMonth(panelStart.Year, panelStart.Month)
+			nrOfMonth := calMonths+2
+			if yearView { year, month, nrOfMonth = d.Year, 1, 12 }
+			var err error
+			wasLoading := loading
+			events, err, loading = loader.Window(year, month, nrOfMonth)
+			loader.Prefetch(year, month, nrOfMonth)
+			watcher.SetFiles(eventFilenames(events))
+			events, specials = splitSpecials(events)
+			events = tagFilter.Apply(events)
+			markSpans(events)
+			if loading != wasLoading {
+				// poll faster while remind is running
+				if loading { Halfdelay(1) } else { Halfdelay(4) }
 			}
 
-			if debug { statusMessage = fmt.Sprintf("Remind took %fs", time.Now().Sub(start).Seconds()) }
+			if (err == nil) != (remindErr == nil) { updateSize = true }
+			remindErr = err
+			errorLines = formatError(remindErr)
+
+			if debug && !loading { statusMessage = fmt.Sprintf("Remind took %fs", loader.LastDuration().Seconds()) }
 			updateEvents = false
+			if updateSize { continue } // apply new layout first
 		}
-		if updateToday && todayWinEnabled {
-			todayMessageLines = getToday(filename, today.Year, today.Month, today.Day, cols-34-wPadding-2)
+		if updateToday && todayWinEnabled && !todayLoading {
+			// picked up on a later iteration, changes while it runs start another run
+			todayLoading = true
+			go func(day Date) {
+				reminders, err := getToday(filename, day, 34-2)
+				todayLoads <- todayLoad{reminders, err}
+			}(today)
 			updateToday = false
 		}
 
+		if search.Active {
+			searchEvents, version, ready := searchIndex.Events(filename, searchYear-searchYears, 1, 12*(2*searchYears+1), loader.Generation())
+			searchLoading = !ready
+			if ready && version != searchVersion {
+				search.Run(searchEvents)
+				searchVersion = version
+			}
+		}
+
+		if pendingSelection != nil {
+			if dayEvents, ok := events[d.NumericString()]; ok {
+				selectedEvent = findEventIndex(dayEvents, *pendingSelection)
+				pendingSelection = nil
+			}
+		}
 		if activeWin != EVENTS_WIN { selectedEvent = -1 } else if selectedEvent == -1 { selectedEvent = 0 }
+		if dayEvents := events[d.NumericString()]; selectedEvent >= len(dayEvents) && selectedEvent > 0 {
+			// events changed e.g. after a reload
+			selectedEvent = len(dayEvents)-1
+			if selectedEvent < 0 { selectedEvent = 0 }
+		}
 
-		eventsWin.Erase()
-		drawEvents(eventsWin, rows-2, cols-34-wPadding, 0, 0, activeWin == EVENTS_WIN, d, events, 0, selectedEvent)
-		eventsWin.Refresh()
+		if yearView {
+			yearWin.Erase()
+			drawYear(yearWin, rows-2, cols, 0, 0, d, today, events, specials)
+			yearWin.Refresh()
+		} else {
+			eventsWin.Erase()
+			if search.Active {
+				drawSearchResults(eventsWin, eventsHeight, cols-34-wPadding, 0, 0, activeWin == EVENTS_WIN, &search, searchLoading)
+			} else if weekView {
+				drawWeek(eventsWin, eventsHeight, cols-34-wPadding, 0, 0, activeWin == EVENTS_WIN, d, events, selectedEvent)
+			} else if dayView {
+				drawDay(eventsWin, eventsHeight, cols-34-wPadding, 0, 0, activeWin == EVENTS_WIN, d, time.Now(), events, selectedEvent)
+			} else {
+				drawEvents(eventsWin, eventsHeight, cols-34-wPadding, 0, 0, activeWin == EVENTS_WIN, d, events, 0, selectedEvent)
+			}
+			if tagFilter.Enabled() {
+				Wattron(eventsWin, COLOR_PAIR(1))
+				Mvwprintw(eventsWin, 0, cols-34-wPadding-stringWidth(tagFilter.String())-4, " " + tagFilter.String() + " ")
+				Wattroff(eventsWin, COLOR_PAIR(1))
+			}
+			eventsWin.Refresh()
 
-		updateCalendar(calWidgetWin, 0, 0, activeWin == CALENDAR_WIN, ys, d, today, events)
-		calWidgetWin.Refresh()
+			if errorHeight > 0 {
+				errorWin.Erase()
+				drawErrors(errorWin, errorHeight, cols-34-wPadding, 0, 0, errorLines)
+				errorWin.Refresh()
+			}
+
+			m := panelStart
+			for _, win := range calWins {
+				active := activeWin == CALENDAR_WIN && m.Month == d.Month && m.Year == d.Year
+				updateCalendar(win, 0, 0, active, GenerateYearStructure(m.Year), m, d, today, events, specials)
+				win.Refresh()
+				m.AddMonth()
+			}
 
-		if todayWinEnabled {
-			todayWin.Erase()
-			drawToday(todayWin, rows-10-2, 34, 0, 0, yOffsetTodayWin, activeWin == TODAY_WIN, todayMessageLines)
-			todayWin.Refresh()
+			if todayWinEnabled && todayFits {
+				todayHeight := rows-10*calMonths-2
+				yOffsetTodayWin = clampScroll(yOffsetTodayWin, len(todayReminders.Lines), todayHeight-2)
+				todayWin.Erase()
+				drawToday(todayWin, todayHeight, 34, 0, 0, yOffsetTodayWin, activeWin == TODAY_WIN, todayReminders)
+				todayWin.Refresh()
+			}
 		}
 
-		drawStatus(statusWin, cols, statusMessage)
+		message := statusMessage
+		if message == "" && errorHeight == 0 && len(errorLines) > 0 { message = errorLines[0] }
+		if message == "" && dayView && !yearView && !search.Active { message = conflictMessage(events[d.NumericString()]) }
+		drawStatus(statusWin, cols, message, keymap.Help(statusCommands), loading)
 		statusWin.Refresh()
 
 		c := Getch()
 		exit := false
-		statusMessage = ""
-		switch(c) {
-			case 'q': 
+		if c != -1 { statusMessage = "" } // keep messages until the next key press
+		command, count, unbound := keymap.Feed(c)
+		if unbound != "" { statusMessage = "Unbound key: " + unbound }
+		if keymap.Pending() != "" { statusMessage = keymap.Pending() }
+		for ; count > 0; count-- {
+		switch(command) {
+			case "quit": 
 				exit = true
-			case 'l', KEY_RIGHT:
-				if activeWin == CALENDAR_WIN { d.AddDay() } 
-			case 'h', KEY_LEFT:
-				if activeWin == CALENDAR_WIN { d.SubtractDay() }
-			case 'j', KEY_DOWN:
+			case "help":
+				runHelp(rows, cols, keymap)
+				updateSize = true
+			case "right":
+				if activeWin == CALENDAR_WIN { d.AddDay() 
+				} else if activeWin == EVENTS_WIN && (weekView || dayView) { d.AddDay(); selectedEvent = 0 }
+			case "left":
+				if activeWin == CALENDAR_WIN { d.SubtractDay() 
+				} else if activeWin == EVENTS_WIN && (weekView || dayView) { d.SubtractDay(); selectedEvent = 0 }
+			case "next-day":
+				d.AddDay()
+				selectedEvent = 0
+			case "prev-day":
+				d.SubtractDay()
+				selectedEvent = 0
+			case "next-week":
+				d.AddWeek()
+				selectedEvent = 0
+			case "prev-week":
+				d.SubtractWeek()
+				selectedEvent = 0
+			case "today":
+				d = today
+				selectedEvent = 0
+			case "goto-date":
+				form := &Form{Title: "Go to date", Fields: []FormField{{Label: "Date"}}}
+				var to Date
+				ok := runForm(rows, cols, form, func(form *Form) (err error) {
+					to, err = parseGotoDate(form.Value("Date"), d, today)
+					return
+				})
+				updateSize = true
+				if !ok { break }
+				d = to
+				selectedEvent = 0
+				statusMessage = dateLabel(d)
+			case "year-view", "open-day":
+				if command == "open-day" && !yearView { break }
+				yearView = !yearView
+				activeWin = CALENDAR_WIN // day and week movements in the overview
+				updateEvents = true
+				updateSize = true
+			case "today-window":
+				if !todayWinEnabled && !todayFits { statusMessage = "Terminal too small for the today window"; break }
+				todayWinEnabled = !todayWinEnabled
+				if !todayWinEnabled && activeWin == TODAY_WIN { activeWin = CALENDAR_WIN }
+				updateToday = true
+				updateSize = true
+			case "week-view":
+				weekView = !weekView
+				dayView = false
+			case "day-view":
+				dayView = !dayView
+				weekView = false
+			case "delete":
+				if activeWin != EVENTS_WIN { statusMessage = "Select an event to delete ( TAB )"; break }
+				dayEvents, ok := events[d.NumericString()]
+				if !ok || selectedEvent < 0 || selectedEvent >= len(dayEvents) { statusMessage = "No event selected"; break }
+				e := dayEvents[selectedEvent]
+				question := "Delete \"" + e.Message + "\"?"
+				if checkMovable(e) != nil { question = "Delete every occurrence of \"" + e.Message + "\"?" }
+				ok = confirm(rows, cols, question)
+				updateSize = true
+				if !ok { statusMessage = "Canceled"; break }
+				if err := deleteRemLine(e); err != nil { statusMessage = "Could not delete: " + err.Error(); break }
+				loader.Invalidate()
+				updateToday = true
+				selectedEvent = 0
+				statusMessage = fmt.Sprintf("Deleted %s:%d", filepath.Base(e.Filename), e.Lineno)
+			case "move":
+				if activeWin != EVENTS_WIN { statusMessage = "Select an event to move ( TAB )"; break }
+				dayEvents, ok := events[d.NumericString()]
+				if !ok || selectedEvent < 0 || selectedEvent >= len(dayEvents) { statusMessage = "No event selected"; break }
+				e := dayEvents[selectedEvent]
+				if err := checkMovable(e); err != nil { statusMessage = "Can not move: " + err.Error(); break }
+				form := &Form{Title: "Move \"" + e.Message + "\"", Fields: []FormField{{Label: "New date", Value: remDate(d)}}}
+				var to Date
+				ok = runForm(rows, cols, form, func(form *Form) (err error) {
+					to, err = parseISODate(form.Value("New date"))
+					return
+				})
+				updateSize = true
+				if !ok { statusMessage = "Canceled"; break }
+				if err := moveRemLine(e, to); err != nil { statusMessage = "Could not move: " + err.Error(); break }
+				loader.Invalidate()
+				updateToday = true
+				d = to
+				selectedEvent = 0
+				statusMessage = "Moved to " + remDate(to)
+			case "search":
+				search.Active = true
+				searchYear = d.Year
+				activeWin = EVENTS_WIN
+				ok := runSearchPrompt(statusWin, cols, &search, func() {
+					searchEvents, version, ready := searchIndex.Events(filename, searchYear-searchYears, 1, 12*(2*searchYears+1), loader.Generation())
+					search.Run(searchEvents)
+					searchVersion = version
+					searchLoading = !ready
+					eventsWin.Erase()
+					drawSearchResults(eventsWin, eventsHeight, cols-34-wPadding, 0, 0, true, &search, searchLoading)
+					eventsWin.Refresh()
+				})
+				updateSize = true
+				if !ok { search.Active = false; break }
+				// first hit on or after the selected day
+				prev := d
+				prev.SubtractDay()
+				if hit, ok := search.Jump(prev, true); ok {
+					d = hit.Date
+					pendingSelection = &hit
+				} else if !searchLoading {
+					statusMessage = "No hits for " + search.Query
+				}
+			case "next-hit", "prev-hit":
+				if !search.Active || len(search.Hits) == 0 { statusMessage = "No search results ( / )"; break }
+				if hit, ok := search.Jump(d, command == "next-hit"); ok {
+					d = hit.Date
+					activeWin = EVENTS_WIN
+					pendingSelection = &hit
+					statusMessage = fmt.Sprintf("Hit %d of %d", search.Current+1, len(search.Hits))
+				}
+			case "close-search":
+				search.Active = false
+			case "tag-filter":
+				form := tagFilterForm(tagFilter)
+				ok := runForm(rows, cols, form, nil)
+				updateSize = true
+				if !ok { break }
+				tagFilter = TagFilter{form.Value("Mode"), parseTagList(form.Value("Tags"))}
+				updateEvents = true
+				statusMessage = "Tag filter off"
+				if tagFilter.Enabled() { statusMessage = "Tag filter " + tagFilter.String() }
+			case "add":
+				target := defaultTargetFile(filename)
+				if dayEvents, ok := events[d.NumericString()]; ok && selectedEvent >= 0 && dayEvents[selectedEvent].Filename != "" {
+					target = dayEvents[selectedEvent].Filename
+				}
+				form := newReminderForm(d, target)
+				var r NewReminder
+				ok := runForm(rows, cols, form, func(form *Form) (err error) {
+					r, target, err = parseReminderForm(form)
+					return
+				})
+				updateSize = true
+				if !ok { statusMessage = "Canceled"; break }
+				if err := appendRemLine(target, r.RemLine()); err != nil {
+					statusMessage = "Could not add reminder: " + err.Error()
+					break
+				}
+				loader.Invalidate()
+				updateToday = true
+				statusMessage = "Added to " + filepath.Base(target) + ": " + r.RemLine()
+			case "down":
 				if activeWin == CALENDAR_WIN { d.AddWeek() 
 				} else if activeWin == EVENTS_WIN { 
 					if dayEvents, ok := events[d.NumericString()]; ok {
@@ -315,15 +771,9 @@ func DrawingLoop(filename string, todayWinEnabled bool, debug bool) {
 						d.AddDay() 
 					}
 				} else if activeWin == TODAY_WIN {
-					yOffsetTodayWin += 1
-					todayHeight := rows-10-2
-					if yOffsetTodayWin > len(todayMessageLines) - (todayHeight-2) {
-						yOffsetTodayWin = len(todayMessageLines) - (todayHeight-2)
-						if yOffsetTodayWin < 0 { yOffsetTodayWin = 0 }
-
-					}
+					yOffsetTodayWin = clampScroll(yOffsetTodayWin+1, len(todayReminders.Lines), rows-10*calMonths-2-2)
 				}
-			case 'k', KEY_UP: 
+			case "up":
 				if activeWin == CALENDAR_WIN { d.SubtractWeek() 
 				} else if activeWin == EVENTS_WIN { 
 					if selectedEvent == 0 {
@@ -335,29 +785,35 @@ func DrawingLoop(filename string, todayWinEnabled bool, debug bool) {
 						selectedEvent--
 					}
 				} else if activeWin == TODAY_WIN {
-					yOffsetTodayWin--
-					if yOffsetTodayWin < 0 {
-						yOffsetTodayWin = 0
-					}
+					yOffsetTodayWin = clampScroll(yOffsetTodayWin-1, len(todayReminders.Lines), rows-10*calMonths-2-2)
 				}
-			case 'J':
-				if activeWin == CALENDAR_WIN { d.AddMonth() }
-			case 'K':
-				if activeWin == CALENDAR_WIN { d.SubtractMonth() }
-			case 9:
+			case "next-month":
+				d.AddMonth()
+				selectedEvent = 0
+			case "prev-month":
+				d.SubtractMonth()
+				selectedEvent = 0
+			case "next-window":
+				if yearView { break }
 				if activeWin == CALENDAR_WIN { activeWin = EVENTS_WIN 
 				} else if activeWin == EVENTS_WIN { 
-					if todayWinEnabled {
+					if todayWinEnabled && todayFits {
 						activeWin = TODAY_WIN 
 					} else { activeWin = CALENDAR_WIN }
 				} else if activeWin == TODAY_WIN { activeWin = CALENDAR_WIN }
 				statusMessage = "Chg Win"
-			case 'e':
+			case "edit":
 				//escaping from curses mode temporarily
 				Endwin()
 				// If there is a selectedEvent go directly to that events filename
 				editorFilename := filename
 				lineno := 0
+				// without a selected event jump to the first reported problem
+				var rerr *RemindError
+				if errors.As(remindErr, &rerr) && len(rerr.Problems) > 0 && selectedEvent < 0 {
+					editorFilename = rerr.Problems[0].Filename
+					lineno = rerr.Problems[0].Lineno
+				}
 				if dayEvents, ok := events[d.NumericString()]; ok && selectedEvent >= 0 {
 					e := dayEvents[selectedEvent]
 					if e.Filename != "" {
@@ -367,26 +823,27 @@ func DrawingLoop(filename string, todayWinEnabled bool, debug bool) {
 						lineno = e.Lineno
 					}
 				}
-				openEditor(editorFilename, lineno)
-				updateEvents = true
+				err := openEditor(editorFilename, lineno)
+				if err != nil { statusMessage = err.Error() }
+				updateSize = true // curses needs a full redraw after the editor
+				loader.Invalidate()
 				updateToday = true
-			case -1: // skip ERR ( see halfdelay )
-			case KEY_RESIZE:
-			default: 
-				statusMessage = fmt.Sprintf("Unbound key: '%c'", c)
+		}
 		}
 		if exit { break	}
 	}
+	return nil
 }
 
+// Trims message to max cells ( see width.go ), cut off messages end with "..."
 func trimMessage(message string, max int) string {
-	if len(message) > max {
+	if stringWidth(message) > max {
 		if max < 1 {
 			message = ""
 		} else if max < 3 {
 			message = "."
 		} else {
-			message = message[:max-3] + "..."
+			message = cutWidth(message, max-3) + "..."
 		}
 	}
 	return message
@@ -414,26 +871,34 @@ func drawEvents(
 	}
 	for count := 0; ; count++ {
 		if row >= h-2+yOffset { break }
-		dateLabel := fmt.Sprintf("%s %d, %d", time.Month(d.Month).String(), d.Day, d.Year)
+		dateLabel := dateLabel(d)
 		attrs := COLOR_PAIR(1)
 		if count == daySelection { attrs |= A_BOLD }
 		if row > yOffset { 
 			Wattron(win, attrs)
-			Mvwprintw(win, y+row-yOffset, w-len(dateLabel)-1-wPadding, dateLabel) 
+			Mvwprintw(win, y+row-yOffset, w-stringWidth(dateLabel)-1-wPadding, dateLabel) 
 			Wattroff(win, attrs)
 		}
 		row++
 		if row >= h-2+yOffset { break }
 
 		if dayEvents, ok := events[d.NumericString()]; ok {
+			// time column, only as wide as the times of the day need
+			timeWidth := 0
+			for _, event := range dayEvents {
+				if n := stringWidth(formatTimeRange(event)); n > timeWidth { timeWidth = n }
+			}
 			for ei, event := range dayEvents {
 				if row > yOffset { 
-					if count == daySelection && ei == eventSelection {  
-						Wattron(win, attrs)
-					}
+					messageAttrs := eventAttrs(event)
+					if count == daySelection && ei == eventSelection { messageAttrs = attrs }
+					message := event.Message
+					if timeWidth > 0 { message = padMessage(formatTimeRange(event), timeWidth) + " " + event.Title() }
+					if event.InSpan() { message += " ( " + spanLabel(event) + " )" }
 					
-					Mvwprintw(win, y+row-yOffset, 1+wPadding, trimMessage(event.Message, maxMessage)) 
-					Wattroff(win, attrs)
+					Wattron(win, messageAttrs)
+					Mvwprintw(win, y+row-yOffset, 1+wPadding, trimMessage(message, maxMessage)) 
+					Wattroff(win, messageAttrs)
 				}
 				row++
 				if row >= h-2+yOffset { break }
@@ -451,37 +916,61 @@ func drawEvents(
 	}
 }
 
-func updateCalendar(win *Window, y int, x int, active bool, ys YearStructure, d Date, today Date, events map[string][]Event) {
-	monthYearLabel := time.Month(d.Month).String() + " " + strconv.Itoa(d.Year)
-	selection := 0
-	todayIndex := -1
+// Keeps d inside the months of the side panel starting at start, the panel only
+// scrolls when d leaves it so the selection moves across the stacked months
+func scrollPanel(start Date, d Date, nrOfMonth int) Date {
+	if start.Year == 0 {
+		// d in the middle at startup
+		start, _ = NewDate(d.Year, d.Month, 1)
+		for i := 0; i < (nrOfMonth-1)/2; i++ { start.SubtractMonth() }
+		return start
+	}
+	monthsBetween := func(a Date, b Date) int { return (b.Year-a.Year)*12 + b.Month - a.Month }
+	for monthsBetween(start, d) < 0 { start.SubtractMonth() }
+	for monthsBetween(start, d) >= nrOfMonth { start.AddMonth() }
+	return start
+}
 
-	daysInMonthPrev := ys.daysInMonths[d.Month-1]
-	daysInMonth := ys.daysInMonths[d.Month]
+// Draws the month of m, d is only selected if it is part of that month
+// ys is the YearStructure of m.Year
+func updateCalendar(
+	win *Window, y int, x int, active bool, ys YearStructure, m Date, d Date, today Date,
+	events map[string][]Event, specials map[string]DaySpecial,
+	) {
+	monthYearLabel := time.Month(m.Month).String() + " " + strconv.Itoa(m.Year)
+	selection := -1
+	todayIndex := -1
+	selectedMonth := m.Month == d.Month && m.Year == d.Year
 
-	wdStart := Weekday(d.Year, time.Month(d.Month), 1)
-	// weekday transform Mo 0 ... Sun 6
-	if wdStart == 0 { wdStart = 7 }; wdStart--
+	daysInMonthPrev := ys.daysInMonths[m.Month-1]
+	daysInMonth := ys.daysInMonths[m.Month]
 
-	wdEnd := Weekday(d.Year, time.Month(d.Month), daysInMonth)
-	if wdEnd == 0 { wdEnd = 7 }; wdEnd--
+	// weekday transform firstWeekday 0 ... 6
+	wdStart := weekdayColumn(m.Year, m.Month, 1)
+	wdEnd := weekdayColumn(m.Year, m.Month, daysInMonth)
 
 	dayNr := 0
-	for _, daysInMonth := range ys.daysInMonths[1:d.Month] {
-		dayNr += daysInMonth
+	if selectedMonth {
+		for _, daysInMonth := range ys.daysInMonths[1:d.Month] {
+			dayNr += daysInMonth
+		}
+		dayNr += d.Day
 	}
-	weekNr := int((dayNr + 1 - 1) / 7) + 1
-	dayNr += d.Day
 
 	days := [42]int{}
 	eventsIndex := [42]bool{}
+	specialsIndex := [42]DaySpecial{}
+	for i := range specialsIndex { specialsIndex[i].Moon = -1 }
+	spansIndex := [42]spanMark{}
 	i := 0
 	for j:=daysInMonthPrev-wdStart+1; j<=daysInMonthPrev; j++ {
 		days[i] = j
 
 		// add events
-		year, month := SubtractMonth(d.Year, d.Month)
+		year, month := SubtractMonth(m.Year, m.Month)
 		if _, ok := events[NumericString(year, month, j)]; ok { eventsIndex[i] = true }
+		if special, ok := specials[NumericString(year, month, j)]; ok { specialsIndex[i] = special }
+		spansIndex[i] = daySpanMark(events[NumericString(year, month, j)])
 
 		if today.Day == j && today.Month == month && today.Year == year { todayIndex = i }
 
@@ -489,12 +978,14 @@ func updateCalendar(win *Window, y int, x int, active bool, ys YearStructure, d
 	}
 	for j:=1; j<=daysInMonth; j++ {
 		days[i] = j
-		if j == d.Day { selection = i }
+		if selectedMonth && j == d.Day { selection = i }
 
 		// add events
-		if _, ok := events[NumericString(d.Year, d.Month, j)]; ok { eventsIndex[i] = true }
+		if _, ok := events[NumericString(m.Year, m.Month, j)]; ok { eventsIndex[i] = true }
+		if special, ok := specials[NumericString(m.Year, m.Month, j)]; ok { specialsIndex[i] = special }
+		spansIndex[i] = daySpanMark(events[NumericString(m.Year, m.Month, j)])
 
-		if today.Day == j && today.Month == d.Month && today.Year == d.Year { todayIndex = i }
+		if today.Day == j && today.Month == m.Month && today.Year == m.Year { todayIndex = i }
 
 		i++
 	}
@@ -502,8 +993,10 @@ func updateCalendar(win *Window, y int, x int, active bool, ys YearStructure, d
 		days[i] = j
 
 		// add events
-		year, month := AddMonth(d.Year, d.Month)
+		year, month := AddMonth(m.Year, m.Month)
 		if _, ok := events[NumericString(year, month, j)]; ok { eventsIndex[i] = true }
+		if special, ok := specials[NumericString(year, month, j)]; ok { specialsIndex[i] = special }
+		spansIndex[i] = daySpanMark(events[NumericString(year, month, j)])
 
 		if today.Day == j && today.Month == month && today.Year == year { todayIndex = i }
 
@@ -511,30 +1004,36 @@ func updateCalendar(win *Window, y int, x int, active bool, ys YearStructure, d
 	}
 
 	weeks := [6]int{}
+	firstOfMonth, _ := NewDate(m.Year, m.Month, 1)
+	rowStart := weekStart(firstOfMonth, firstWeekday)
 	for i:=0; i<6; i++ {
-		weeks[i] = weekNr + i
+		weeks[i] = isoWeekOf(rowStart)
+		rowStart.AddWeek()
 	}
 	
-	drawCalendar(win, y, x, active, monthYearLabel, days, weeks, dayNr, selection, todayIndex, eventsIndex)
+	drawCalendar(win, y, x, active, monthYearLabel, days, weeks, dayNr, selection, todayIndex, eventsIndex, specialsIndex, spansIndex)
 }
 
 // Draws fixed length calendar widget height=10, width=34
 // does not require erase overwrites old spots
+// selection and dayNr are left out if they are -1 and 0
 // if any day is 0 entire row is left empty
+// SHADE days are drawn on their color, MOON phases next to the day number
+// multi-day spans are underlined through the days they cover
 func drawCalendar(
 	win *Window, y int, x int, active bool, 
 	monthYearLabel string, days [42]int, weeks[6]int, dayNr int, 
-	selection int, todayIndex int, eventsIndex [42]bool,
+	selection int, todayIndex int, eventsIndex [42]bool, specialsIndex [42]DaySpecial, spansIndex [42]spanMark,
 	) {
 
-	weekdays := "Mon Tue Wed Thu Fri Sat Sun"
+	weekdays := weekdayHeader()
 
 	if active { Wattron(win, COLOR_PAIR(1)) } 
 	win.Box(0, 0)
 	Wattron(win, COLOR_PAIR(1))
-	Mvwprintw(win, y, x+27, fmt.Sprintf("(#%3d)", dayNr))
+	if dayNr > 0 { Mvwprintw(win, y, x+27, fmt.Sprintf("(#%3d)", dayNr)) }
 	Mvwprintw(win, y+1,x+5, "                           ")
-	Mvwprintw(win, y+1,x+5+(len(weekdays)-len(monthYearLabel))/2, monthYearLabel)
+	Mvwprintw(win, y+1,x+5+(stringWidth(weekdays)-stringWidth(monthYearLabel))/2, monthYearLabel)
 	Mvwprintw(win, y+2,x+5, weekdays)
 	Wattroff(win, COLOR_PAIR(1))
 
@@ -547,11 +1046,24 @@ func drawCalendar(
 			// if any day is 0 entire row is skipped
 			if d == 0 { emptyRow = true; break } 
 
-			if eventsIndex[count] { Wattron(win, COLOR_PAIR(2)) }
-			if todayIndex == count { Wattron(win, COLOR_PAIR(3)) }
-			Mvwprintw(win, y+3+row, x+1+4+col*4, fmt.Sprintf(" %2d ", d))
-			Wattroff(win, COLOR_PAIR(2))
-			Wattroff(win, COLOR_PAIR(3))
+			attrs, fg := 0, -1
+			if eventsIndex[count] { attrs, fg = COLOR_PAIR(2), eventsFg }
+			if todayIndex == count { attrs, fg = COLOR_PAIR(3), todayFg }
+			if shade := shadeAttrs(specialsIndex[count], fg); shade != 0 { attrs = shade }
+			cell := fmt.Sprintf(" %2d ", d)
+			if moon := specialsIndex[count].Moon; moon >= 0 { cell = fmt.Sprintf(" %2d%s", d, moonGlyphs[moon]) }
+			Wattron(win, attrs)
+			Mvwprintw(win, y+3+row, x+1+4+col*4, cell)
+			Wattroff(win, attrs)
+			if span := spansIndex[count]; span.in {
+				// the day number and the gaps to the neighbouring days of the span
+				start, end := 1, 3
+				if span.prev && col > 0 { start = 0 }
+				if span.next && col < 6 { end = 4 }
+				Wattron(win, attrs | A_UNDERLINE)
+				Mvwprintw(win, y+3+row, x+1+4+col*4+start, string([]rune(cell)[start:end]))
+				Wattroff(win, attrs | A_UNDERLINE)
+			}
 			count++
 		}
 		weekLabel := fmt.Sprintf(" %2d ", weeks[row])
@@ -565,6 +1077,7 @@ func drawCalendar(
 		Wattroff(win, COLOR_PAIR(1))
 	}
 	// add selection
+	if selection < 0 { return }
 	selectedCalRow := int(selection/7)
 	selectedCalCol := int(math.Abs(float64(selection%7)))
 
@@ -590,29 +1103,110 @@ func drawBox(win *Window, height int, width int, y int, x int) {
 	Mvwhline(win, y+height-1, x+1, ACS_HLINE, width-2)
 }
 
-func drawToday(win *Window, h int, w int, y int, x int, yOffset int, active bool, lines []string) {
+// Index of e in dayEvents matched by source line and message, 0 if not found
+func findEventIndex(dayEvents []Event, e Event) int {
+	for i, de := range dayEvents {
+		if de.Filename == e.Filename && de.Lineno == e.Lineno && de.Message == e.Message { return i }
+	}
+	return 0
+}
+
+// Returns the distinct files the events originate from
+func eventFilenames(events map[string][]Event) []string {
+	seen := map[string]bool{}
+	filenames := []string{}
+	for _, dayEvents := range events {
+		for _, e := range dayEvents {
+			if e.Filename == "" || seen[e.Filename] { continue }
+			seen[e.Filename] = true
+			filenames = append(filenames, e.Filename)
+		}
+	}
+	return filenames
+}
+
+// Draws remind problems as "file:line: message" inside a red box
+func drawErrors(win *Window, h int, w int, y int, x int, lines []string) {
+	Wattron(win, COLOR_PAIR(1))
+	drawBox(win, h, w, y, x)
+	Mvwprintw(win, y, x+2, " Remind Error ")
+	Wattroff(win, COLOR_PAIR(1))
+
 	for row, line := range lines {
-		Mvwprintw(win, 1+row-yOffset, 1, line)
+		if row >= h-2 { break }
+		Mvwprintw(win, y+1+row, x+2, trimMessage(line, w-4))
+	}
+}
+
+// Turns an error from remind into lines for the error window
+func formatError(err error) []string {
+	lines := []string{}
+	if err == nil { return lines }
+
+	var rerr *RemindError
+	if !errors.As(err, &rerr) { return []string{err.Error()} }
+	if len(rerr.Problems) == 0 {
+		for _, line := range strings.Split(strings.TrimSpace(rerr.Error()), "\n") {
+			lines = append(lines, line)
+		}
+		return lines
 	}
+	for _, p := range rerr.Problems {
+		lines = append(lines, fmt.Sprintf("%s:%d: %s", filepath.Base(p.Filename), p.Lineno, p.Message))
+	}
+	return lines
+}
 
+// Draws the reminders of the day starting at line yOffset, the title holds
+// the date and the bottom border the number of reminders and advance warnings
+func drawToday(win *Window, h int, w int, y int, x int, yOffset int, active bool, today TodayReminders) {
+	if h < 3 || w < 3 { return } // too small for the box
 	if active { Wattron(win, COLOR_PAIR(1)) }
 	drawBox(win, h, w, y, x)
 	Wattroff(win, COLOR_PAIR(1))
+
+	d := today.Date
+	wd := strings.TrimSpace(weekdayAbbr(time.Weekday(Weekday(d.Year, time.Month(d.Month), d.Day))))
+	Wattron(win, COLOR_PAIR(1))
+	Mvwprintw(win, y, x+2, trimMessage(" Today, " + wd + " " + dateLabel(d) + " ", w-4))
+	Wattroff(win, COLOR_PAIR(1))
+	counters := fmt.Sprintf(" %d today ", today.Count)
+	if today.Upcoming > 0 { counters = fmt.Sprintf(" %d today, %d upcoming ", today.Count, today.Upcoming) }
+	Mvwprintw(win, y+h-1, x+w-2-stringWidth(counters), trimMessage(counters, w-4))
+
+	lines := today.Lines
+	if len(lines) == 0 { lines = []string{"No reminders"} }
+	for row := 0; row < h-2 && yOffset+row < len(lines); row++ {
+		Mvwprintw(win, y+1+row, x+1, trimMessage(lines[yOffset+row], w-2))
+	}
 }
 
-func drawStatus(win *Window, width int, message string) {
+// Keeps a scroll offset within the lines that do not fit into visible rows
+func clampScroll(offset int, lines int, visible int) int {
+	if offset > lines-visible { offset = lines-visible }
+	if offset < 0 { offset = 0 }
+	return offset
+}
+
+func drawStatus(win *Window, width int, message string, help string, loading bool) {
 	padding := 1
 	maxMessage := width-2*padding
 
 	// status message
 	Wattron(win, COLOR_PAIR(5))
 	Mvwhline(win, 0, 0, ACS_HLINE, width)
-	if len(message) > maxMessage { message = message[:maxMessage] }
+	loadingLabel := " loading... "
+	if loading { maxMessage -= len(loadingLabel) }
+	if maxMessage < 0 { maxMessage = 0 }
+	if stringWidth(message) > maxMessage { message = cutWidth(message, maxMessage) }
 	Mvwprintw(win, 0, padding, message)
+	if loading && width-padding-len(loadingLabel) > 0 {
+		Mvwprintw(win, 0, width-padding-len(loadingLabel), loadingLabel)
+	}
 	Wattroff(win, COLOR_PAIR(5))
 
 	// controls
-	Mvwprintw(win, 1, padding, "q:Quit TAB:ChgWin  e:Edit  h:Left  j:Down  k:Up  l:Right")
+	Mvwprintw(win, 1, padding, trimMessage(help, width-2*padding))
 }
 
 
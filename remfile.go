@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repeat rules offered when creating a reminder
+var repeatRules = []string{"none", "daily", "weekly", "monthly", "yearly"}
+
+// Description of a new reminder, converted into a REM line by RemLine
+type NewReminder struct {
+	Date Date
+	Message string
+	At int       // minutes after midnight, -1 if untimed
+	Duration int // minutes, 0 if none
+	Through *Date // last day, nil if open ended
+	Repeat string // one of repeatRules
+}
+
+// ISO dates are understood by all recent remind versions
+func remDate(d Date) string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Generates the REM line for r
+// one-off:  REM 2026-10-17 [THROUGH 2026-10-20] [AT 9:30 [DURATION 1:00]] MSG ...
+// repeated: REM <trigger> [*N] [FROM start] [UNTIL end] ...
+func (r NewReminder) RemLine() string {
+	parts := []string{"REM"}
+	month := time.Month(r.Date.Month).String()[:3]
+	switch r.Repeat {
+	case "daily":
+		parts = append(parts, remDate(r.Date))
+		if r.Through != nil {
+			parts = append(parts, "THROUGH", remDate(*r.Through))
+		} else {
+			parts = append(parts, "*1")
+		}
+	case "weekly":
+		parts = append(parts, remDate(r.Date), "*7")
+		if r.Through != nil { parts = append(parts, "UNTIL", remDate(*r.Through)) }
+	case "monthly":
+		parts = append(parts, strconv.Itoa(r.Date.Day), "FROM", remDate(r.Date))
+		if r.Through != nil { parts = append(parts, "UNTIL", remDate(*r.Through)) }
+	case "yearly":
+		parts = append(parts, month, strconv.Itoa(r.Date.Day), "FROM", remDate(r.Date))
+		if r.Through != nil { parts = append(parts, "UNTIL", remDate(*r.Through)) }
+	default:
+		parts = append(parts, remDate(r.Date))
+		if r.Through != nil { parts = append(parts, "THROUGH", remDate(*r.Through)) }
+	}
+	if r.At >= 0 {
+		parts = append(parts, "AT", fmt.Sprintf("%d:%02d", r.At/60, r.At%60))
+		if r.Duration > 0 {
+			parts = append(parts, "DURATION", fmt.Sprintf("%d:%02d", r.Duration/60, r.Duration%60))
+		}
+	}
+	parts = append(parts, "MSG", escapeRemMessage(r.Message))
+	return strings.Join(parts, " ")
+}
+
+// % starts a substitution and [ an expression in a MSG body
+func escapeRemMessage(message string) string {
+	message = strings.ReplaceAll(message, "%", "%%")
+	message = strings.ReplaceAll(message, "[", `["["]`)
+	return strings.ReplaceAll(message, "\n", " ")
+}
+
+var clockRegex = regexp.MustCompile(`^(\d{1,2})(?::?(\d{2}))?\s*([aApP][mM])?$`)
+
+// Parses a time of day like 9:30, 0930, 9pm into minutes after midnight
+func parseClock(str string) (int, error) {
+	m := clockRegex.FindStringSubmatch(strings.TrimSpace(str))
+	if m == nil { return 0, fmt.Errorf("Invalid time %q, use HH:MM", str) }
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" { minute, _ = strconv.Atoi(m[2]) }
+	switch strings.ToLower(m[3]) {
+	case "am": if hour == 12 { hour = 0 }
+	case "pm": if hour < 12 { hour += 12 }
+	}
+	if hour > 23 || minute > 59 { return 0, fmt.Errorf("Invalid time %q", str) }
+	return hour*60 + minute, nil
+}
+
+// Parses a duration like 1:30 or 90 ( minutes ) into minutes
+func parseDuration(str string) (int, error) {
+	str = strings.TrimSpace(str)
+	if h, m, found := strings.Cut(str, ":"); found {
+		hours, err1 := strconv.Atoi(h)
+		minutes, err2 := strconv.Atoi(m)
+		if err1 != nil || err2 != nil || hours < 0 || minutes < 0 || minutes > 59 {
+			return 0, fmt.Errorf("Invalid duration %q, use H:MM", str)
+		}
+		return hours*60 + minutes, nil
+	}
+	minutes, err := strconv.Atoi(str)
+	if err != nil || minutes < 0 { return 0, fmt.Errorf("Invalid duration %q, use H:MM or minutes", str) }
+	return minutes, nil
+}
+
+// Parses YYYY-MM-DD into a Date
+func parseISODate(str string) (Date, error) {
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(str))
+	if err != nil { return Date{}, fmt.Errorf("Invalid date %q, use YYYY-MM-DD", str) }
+	return NewDate(t.Year(), int(t.Month()), t.Day())
+}
+
+// File new reminders are written to by default, filename itself or
+// the first *.rem file if filename is a directory
+func defaultTargetFile(filename string) string {
+	info, err := os.Stat(filename)
+	if err != nil || !info.IsDir() { return filename }
+	remFiles, _ := filepath.Glob(filepath.Join(filename, "*.rem"))
+	if len(remFiles) > 0 { return remFiles[0] }
+	return filepath.Join(filename, "reminders.rem")
+}
+
+// Appends line to filename, the file is created if it does not exist
+func appendRemLine(filename string, line string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) { return err }
+
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil { return err }
+	defer f.Close()
+
+	if len(content) > 0 && content[len(content)-1] != '\n' { line = "\n" + line }
+	_, err = f.WriteString(line + "\n")
+	return err
+}
+
+// Form to create a reminder on date d, written to target by default
+func newReminderForm(d Date, target string) *Form {
+	return &Form{
+		Title: "New Reminder",
+		Fields: []FormField{
+			{Label: "Date", Value: remDate(d)},
+			{Label: "Message"},
+			{Label: "At (HH:MM)"},
+			{Label: "Duration (H:MM)"},
+			{Label: "Through (YYYY-MM-DD)"},
+			{Label: "Repeat", Value: repeatRules[0], Options: repeatRules},
+			{Label: "File", Value: target},
+		},
+		selected: 1, // date is already filled in
+	}
+}
+
+// Validates the new reminder form and returns the reminder and its target file
+func parseReminderForm(form *Form) (r NewReminder, target string, err error) {
+	r.At = -1
+	r.Repeat = form.Value("Repeat")
+	r.Message = strings.TrimSpace(form.Value("Message"))
+	if r.Message == "" { return r, "", fmt.Errorf("Message must not be empty") }
+
+	r.Date, err = parseISODate(form.Value("Date"))
+	if err != nil { return }
+	if at := strings.TrimSpace(form.Value("At (HH:MM)")); at != "" {
+		r.At, err = parseClock(at)
+		if err != nil { return }
+	}
+	if duration := strings.TrimSpace(form.Value("Duration (H:MM)")); duration != "" {
+		if r.At < 0 { return r, "", fmt.Errorf("Duration requires an At time") }
+		r.Duration, err = parseDuration(duration)
+		if err != nil { return }
+	}
+	if through := strings.TrimSpace(form.Value("Through (YYYY-MM-DD)")); through != "" {
+		end, err := parseISODate(through)
+		if err != nil { return r, "", err }
+		if dateBefore(end, r.Date) { return r, "", fmt.Errorf("Through date is before the start date") }
+		r.Through = &end
+	}
+
+	target = strings.TrimSpace(form.Value("File"))
+	if target == "" { return r, "", fmt.Errorf("File must not be empty") }
+	return r, target, nil
+}
+
+func dateBefore(a Date, b Date) bool {
+	if a.Year != b.Year { return a.Year < b.Year }
+	if a.Month != b.Month { return a.Month < b.Month }
+	return a.Day < b.Day
+}
+
+// Reads the file of e and returns its lines and the 0-based index range
+// [start, end] of the REM line e originates from ( continued lines span multiple lines )
+// Fails if the line does not look like the REM line remind reported e.g. because
+// the file changed since the events were loaded
+func findRemLine(e Event) (lines []string, start int, end int, err error) {
+	if e.Filename == "" || e.Lineno < 1 { return nil, 0, 0, fmt.Errorf("Reminder has no source line") }
+	content, err := os.ReadFile(e.Filename)
+	if err != nil { return nil, 0, 0, err }
+	lines = strings.Split(string(content), "\n")
+
+	start, end = e.LinenoStart-1, e.Lineno-1
+	if start < 0 || start > end { start = end }
+	if end >= len(lines) { return nil, 0, 0, fmt.Errorf("%s changed, line %d does not exist", filepath.Base(e.Filename), e.Lineno) }
+
+	remLine := joinRemLine(lines[start:end+1])
+	fields := strings.Fields(remLine)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "REM") {
+		return nil, 0, 0, fmt.Errorf("%s:%d is not a REM line", filepath.Base(e.Filename), e.Lineno)
+	}
+	if e.RawBody != "" && !strings.Contains(remLine, strings.TrimSpace(e.RawBody)) {
+		return nil, 0, 0, fmt.Errorf("%s:%d changed since it was loaded", filepath.Base(e.Filename), e.Lineno)
+	}
+	return lines, start, end, nil
+}
+
+// joins lines continued with a trailing backslash
+func joinRemLine(lines []string) string {
+	joined := ""
+	for _, line := range lines {
+		joined += strings.TrimSuffix(line, "\\")
+	}
+	return joined
+}
+
+func writeLines(filename string, lines []string) error {
+	info, err := os.Stat(filename)
+	if err != nil { return err }
+	return os.WriteFile(filename, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
+
+// Removes the REM line e originates from, this removes all occurrences of e
+func deleteRemLine(e Event) error {
+	lines, start, end, err := findRemLine(e)
+	if err != nil { return err }
+	lines = append(lines[:start], lines[end+1:]...)
+	return writeLines(e.Filename, lines)
+}
+
+// Returns why e cannot be moved to another date or nil if it is a one-off reminder
+func checkMovable(e Event) error {
+	switch {
+	case e.NonConstExpr:
+		return fmt.Errorf("Date is computed by an expression, edit it with 'e' instead")
+	case e.Rep > 0 && e.Until != "":
+		return fmt.Errorf("Reminder spans multiple days ( THROUGH / UNTIL ), edit it with 'e' instead")
+	case e.Rep > 0 || len(e.TrigWeekdays) > 0 || e.TrigYear == 0 || e.TrigMonth == 0 || e.TrigDay == 0:
+		return fmt.Errorf("Reminder is recurring, moving it would move every occurrence")
+	case e.Until != "":
+		return fmt.Errorf("Reminder has an UNTIL date, edit it with 'e' instead")
+	}
+	return nil
+}
+
+// keywords taking an argument that could be mistaken for part of a date
+var remArgKeywords = map[string]bool{
+	"AT": true, "DURATION": true, "PRIORITY": true, "SCHED": true, "WARN": true,
+	"TAG": true, "INFO": true, "OMITFUNC": true, "SCANFROM": true, "FROM": true,
+	"UNTIL": true, "THROUGH": true,
+}
+// keywords starting the body, no trigger tokens after them
+var remBodyKeywords = map[string]bool{
+	"MSG": true, "MSF": true, "RUN": true, "CAL": true, "SATISFY": true,
+	"SPECIAL": true, "PS": true, "PSFILE": true,
+}
+
+var isoDateRegex = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}$`)
+
+// Moves a one-off reminder to date to by replacing the date tokens of its REM line
+// with an ISO date, recurring or computed reminders are refused
+func moveRemLine(e Event, to Date) error {
+	if err := checkMovable(e); err != nil { return err }
+	lines, start, end, err := findRemLine(e)
+	if err != nil { return err }
+	if start != end { return fmt.Errorf("Continued REM lines can not be moved, edit it with 'e' instead") }
+
+	tokens := strings.Fields(lines[start])
+	dateTokens := []int{}
+	for i := 1; i < len(tokens); i++ {
+		upper := strings.ToUpper(tokens[i])
+		if remBodyKeywords[upper] { break }
+		if remArgKeywords[upper] {
+			i++
+			if upper == "AT" && i+1 < len(tokens) && strings.HasPrefix(tokens[i+1], "+") { i++ } // AT 9:30 +15
+			continue
+		}
+		if isDateToken(tokens[i]) { dateTokens = append(dateTokens, i) }
+	}
+
+	valid := len(dateTokens) == 1 && isoDateRegex.MatchString(tokens[dateTokens[0]])
+	valid = valid || (len(dateTokens) == 3 && dateTokens[2]-dateTokens[0] == 2)
+	if !valid { return fmt.Errorf("Could not find the date in %s:%d, edit it with 'e' instead", filepath.Base(e.Filename), e.Lineno) }
+
+	// keep everything but the date tokens, including indentation
+	indent := lines[start][:len(lines[start])-len(strings.TrimLeft(lines[start], " \t"))]
+	newTokens := append([]string{}, tokens[:dateTokens[0]]...)
+	newTokens = append(newTokens, remDate(to))
+	newTokens = append(newTokens, tokens[dateTokens[len(dateTokens)-1]+1:]...)
+	lines[start] = indent + strings.Join(newTokens, " ")
+	return writeLines(e.Filename, lines)
+}
+
+// day number, year, month name ( remind accepts abbreviations ) or ISO date
+// +N, -N and *N are deltas and repeats, Atoi would accept the sign as part of a number
+func isDateToken(token string) bool {
+	if isoDateRegex.MatchString(token) { return true }
+	if strings.HasPrefix(token, "+") || strings.HasPrefix(token, "-") || strings.HasPrefix(token, "*") { return false }
+	if n, err := strconv.Atoi(token); err == nil { return (n >= 1 && n <= 31) || n >= 1990 }
+	if len(token) < 3 { return false }
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), strings.ToLower(token)) { return true }
+	}
+	return false
+}
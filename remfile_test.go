@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveRemLine(t *testing.T) {
+	to, _ := NewDate(2026, 10, 20)
+	tests := []struct {
+		line string
+		want string // empty if the move is refused
+	}{
+		{"REM 2026-10-17 MSG plain", "REM 2026-10-20 MSG plain"},
+		{"REM 2026-10-17 +3 MSG x", "REM 2026-10-20 +3 MSG x"},
+		{"REM 17 Oct 2026 +3 MSG x", "REM 2026-10-20 +3 MSG x"},
+		{"REM Oct 17 2026 ++3 -1 MSG x", "REM 2026-10-20 ++3 -1 MSG x"},
+		{"REM 2026-10-17 AT 9:30 +15 MSG x", "REM 2026-10-20 AT 9:30 +15 MSG x"},
+		{"REM 2026-10-17 AT 9:30 +15 *5 DURATION 1:00 MSG x", "REM 2026-10-20 AT 9:30 +15 *5 DURATION 1:00 MSG x"},
+		{"  REM 17 October 2026 PRIORITY 1000 MSG on 3 Oct", "  REM 2026-10-20 PRIORITY 1000 MSG on 3 Oct"},
+		{"REM 17 MSG no month", ""},
+	}
+	for _, test := range tests {
+		filename := filepath.Join(t.TempDir(), "test.rem")
+		if err := os.WriteFile(filename, []byte("# before\n" + test.line + "\n"), 0644); err != nil { t.Fatal(err) }
+		e := Event{Filename: filename, Lineno: 2, LinenoStart: 2, TrigYear: 2026, TrigMonth: 10, TrigDay: 17}
+		err := moveRemLine(e, to)
+		if test.want == "" {
+			if err == nil { t.Errorf("moveRemLine(%q) succeeded, want an error", test.line) }
+			continue
+		}
+		if err != nil { t.Errorf("moveRemLine(%q): %s", test.line, err); continue }
+		content, err := os.ReadFile(filename)
+		if err != nil { t.Fatal(err) }
+		if got := strings.Split(string(content), "\n")[1]; got != test.want {
+			t.Errorf("moveRemLine(%q) wrote %q, want %q", test.line, got, test.want)
+		}
+	}
+}
@@ -9,71 +9,187 @@ import (
 	"strconv"
 	"time"
 	"encoding/json"
+	"regexp"
 	"path/filepath"
 )
 
-// Gets reminders for today and puts the output into array of lines ([]string) 
-// If lines are longer than maxLineWidth they are cut off and the remainder(s) added as new line(s)
-func getToday(filename string, year int, month int, day int, maxLineWidth int) []string {
+// Error returned whenever remind fails or reports problems in the reminder files
+// Stderr holds the raw output, Problems the lines that could be parsed into
+// filename(lineno): message
+type RemindError struct {
+	Err error
+	Stderr string
+	Problems []RemindProblem
+}
+type RemindProblem struct {
+	Filename string
+	Lineno int
+	Message string
+}
+func (e *RemindError) Error() string {
+	if len(e.Problems) > 0 {
+		p := e.Problems[0]
+		return fmt.Sprintf("%s(%d): %s", p.Filename, p.Lineno, p.Message)
+	}
+	if e.Stderr != "" { return strings.TrimSpace(e.Stderr) }
+	if e.Err != nil { return e.Err.Error() }
+	return "remind failed"
+}
+func (e *RemindError) Unwrap() error {
+	return e.Err
+}
+
+// matches remind error lines e.g. /home/user/.reminders(12): Expecting number
+var remindProblemRegex = regexp.MustCompile(`^(.+)\((\d+)\): (.*)$`)
+
+func parseRemindStderr(stderr string) []RemindProblem {
+	problems := []RemindProblem{}
+	for _, line := range strings.Split(stderr, "\n") {
+		m := remindProblemRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil { continue }
+		lineno, err := strconv.Atoi(m[2])
+		if err != nil { continue }
+		problems = append(problems, RemindProblem{m[1], lineno, m[3]})
+	}
+	return problems
+}
+
+// remind binary and extra arguments passed before args ( see Config )
+var remindCommand = "remind"
+var remindArgs = []string{}
+
+// Runs remind with args and returns stdout
+// If remind fails or complains about the reminder files a *RemindError is returned
+func runRemind(args ...string) (string, error) {
+	return runRemindInput("", args...)
+}
+
+// Like runRemind with input as stdin, used with "-" as filename to run a script
+func runRemindInput(input string, args ...string) (string, error) {
 	var outb, errb bytes.Buffer
-	dateStr := fmt.Sprintf("%04d-%02d-%02d", year, month, day)
-	cmd := exec.Command("remind", filename, dateStr)	
-    cmd.Stdout = &outb
+	cmd := exec.Command(remindCommand, append(append([]string{}, remindArgs...), args...)...)
+	if input != "" { cmd.Stdin = strings.NewReader(input) }
+	cmd.Stdout = &outb
 	cmd.Stderr = &errb
 
 	err := cmd.Run()
-	if err != nil { 
-		if errb.Len() > 0 { err = fmt.Errorf(errb.String()) }
-		panic(err) 
+	problems := parseRemindStderr(errb.String())
+	if err != nil || len(problems) > 0 {
+		return outb.String(), &RemindError{err, errb.String(), problems}
 	}
 	if outb.Len() <= 0 {
-		panic(fmt.Errorf("remind did not return any output"))
+		return "", &RemindError{fmt.Errorf("remind did not return any output"), errb.String(), problems}
 	}
+	return outb.String(), nil
+}
+
+// Reminders remind issues on a day, shown in the today window
+// Months of calendar output searched for advance warnings at most
+const maxTodayMonths = 24
 
-	// entire outb is transformed into lines wrapped if too long
-	lines := []string{}
-	for i, line := range strings.Split(outb.String(), "\n") {
-		if i == 0 {
-			// patching first line because it is so long
-			lines = append(lines, "Todays Reminders:")
+type TodayReminders struct {
+	Date Date
+	Lines []string // reminders wrapped to the window width, separated by an empty line
+	Count int // reminders on the day itself
+	Upcoming int // advance warnings of later reminders ( REM ... +N )
+}
+
+// Result of getToday running in the background
+type todayLoad struct {
+	reminders TodayReminders
+	err error
+}
+
+// Gets the reminders for day from remind, wrapped at spaces to width cells
+// The counters are taken from the calendar output starting at the month of day,
+// it reaches as far as the longest advance warning of the reminder files
+// This runs remind twice and reads all files, call it outside of the ui goroutine
+func getToday(filename string, day Date, width int) (TodayReminders, error) {
+	today := TodayReminders{Date: day}
+	out, err := runRemind(filename, remDate(day))
+	if err != nil { return today, err }
+
+	blank := true
+	for i, line := range strings.Split(out, "\n") {
+		// the date is shown in the window title instead of the banner
+		if i == 0 && strings.HasPrefix(line, "Reminders for") { continue }
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if !blank { today.Lines = append(today.Lines, "") }
+			blank = true
 			continue
 		}
-		for {
-			if len(line) <= maxLineWidth {
-				lines = append(lines, line)
-				break
-			} else {
-				lines = append(lines, line[:maxLineWidth])
-				line = line[maxLineWidth:]
-			}
+		today.Lines = append(today.Lines, wrapWords(line, width)...)
+		blank = false
+	}
+	if blank && len(today.Lines) > 0 { today.Lines = today.Lines[:len(today.Lines)-1] }
+
+	maxDelta := maxRemDelta(append([]string{filename}, findIncludes(filename)...))
+	last := time.Date(day.Year, time.Month(day.Month), day.Day+maxDelta, 0, 0, 0, 0, time.UTC)
+	months := (last.Year()-day.Year)*12 + int(last.Month()) - day.Month + 1
+	if months > maxTodayMonths { months = maxTodayMonths }
+	eventsArr, err := getEvents(filename, day.Year, day.Month, months)
+	if eventsArr == nil && err != nil { return today, err }
+	warned := map[spanKey]bool{} // only the next occurrence of a reminder is announced
+	for _, e := range eventsArr {
+		if e.IsDaySpecial() { continue }
+		days := daysBetween(day, e.Date)
+		if days == 0 { today.Count++ }
+		key := spanKey{e.Filename, e.Lineno}
+		delta := e.Delta
+		if delta < 0 { delta = -delta } // ++N, OMITs are ignored
+		if days > 0 && days <= delta && !warned[key] {
+			today.Upcoming++
+			warned[key] = true
 		}
 	}
+	return today, nil
+}
+
+var remDeltaRegex = regexp.MustCompile(`^\+\+?(\d+)$`)
 
-	return lines
+// Longest advance warning ( REM ... +N or ++N ) in files, directories are
+// searched for *.rem files like remind does. Deltas computed by expressions are not found
+func maxRemDelta(files []string) (maxDelta int) {
+	for _, filename := range files {
+		paths := []string{filename}
+		if info, err := os.Stat(filename); err == nil && info.IsDir() {
+			paths, _ = filepath.Glob(filepath.Join(filename, "*.rem"))
+		}
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil { continue }
+			for _, line := range strings.Split(string(content), "\n") {
+				tokens := strings.Fields(line)
+				if len(tokens) == 0 || !strings.EqualFold(tokens[0], "REM") { continue }
+				for _, token := range tokens[1:] {
+					if remBodyKeywords[strings.ToUpper(token)] { break }
+					m := remDeltaRegex.FindStringSubmatch(token)
+					if m == nil { continue }
+					if delta, _ := strconv.Atoi(m[1]); delta > maxDelta { maxDelta = delta }
+				}
+			}
+		}
+	}
+	return
 }
 
 // Calls remind -pppn -g filename date and parses returned reminders into []Event
 // All returned dates are valid
-func getEvents(filename string, year int, month int, nrOfMonth int) (eventsArr []Event) {
-	var outb, errb bytes.Buffer
+// If remind reports problems but still produced output the parsed events
+// are returned together with the *RemindError
+func getEvents(filename string, year int, month int, nrOfMonth int) ([]Event, error) {
 	dateStr := fmt.Sprintf("%04d-%02d-%02d", year, month, 1)
-
-	cmd := exec.Command("remind", "-ppp" + strconv.Itoa(nrOfMonth), "-g", filename, dateStr)	
-    cmd.Stdout = &outb
-	cmd.Stderr = &errb
-
-	err := cmd.Run()
-	if err != nil { 
-		if errb.Len() > 0 { err = fmt.Errorf(errb.String()) }
-		panic(err) 
-	}
-	if outb.Len() <= 0 {
-		panic(fmt.Errorf("remind did not return any output"))
+	args := append(weekStartRemindArgs(), "-ppp" + strconv.Itoa(nrOfMonth), "-g", filename, dateStr)
+	out, err := runRemind(args...)
+	if err != nil {
+		if out == "" { return nil, err }
+		eventsArr, perr := parseRemindEventsJSON(out)
+		if perr != nil { return nil, err }
+		return eventsArr, err
 	}
 
-	eventsArr, err = parseRemindEventsJSON(outb.String())
-	if err != nil { panic(err) }
-	return eventsArr
+	return parseRemindEventsJSON(out)
 }
 
 // Parses pure JSON output ( remind -ppp )
@@ -86,7 +202,30 @@ func parseRemindEventsJSON(str string) ([]Event, error) {
 		Date string
 		Filename string
 		Lineno int
+		LinenoStart int `json:"lineno_start"`
+		Passthru string
+		Tags string
+		Time *int
+		Duration *int
+		EventStart string
+		EventDuration *int
+		Priority *int
+		NonConstExpr int `json:"nonconst_expr"`
+		RawBody string
 		Body string
+		R *int
+		G *int
+		B *int
+
+		// trigger
+		D int
+		M int
+		Y int
+		Wd []string
+		Delta int
+		Back int
+		Rep int
+		Until string
 	}
 	type MonthDescriptor struct { Entries []Entry }
 	
@@ -105,6 +244,43 @@ func parseRemindEventsJSON(str string) ([]Event, error) {
 
 		event.Filename = entry.Filename
 		event.Lineno = entry.Lineno
+		event.LinenoStart = entry.Lineno
+		if entry.LinenoStart > 0 { event.LinenoStart = entry.LinenoStart }
+
+		if entry.Time != nil { event.Time = *entry.Time }
+		if entry.Duration != nil { event.Duration = *entry.Duration }
+		if entry.EventStart != "" {
+			event.EventStart, err = time.ParseInLocation("2006-01-02T15:04", entry.EventStart, time.Local)
+			if err != nil { 
+				return eventsArr, fmt.Errorf("Could not parse REM Event start %s: %w", entry.EventStart, err) 
+			}
+		}
+		if entry.EventDuration != nil { event.EventDuration = *entry.EventDuration }
+
+		event.Tags = parseTags(entry.Tags)
+		event.Passthru = entry.Passthru
+		if isColorSpecial(entry.Passthru) {
+			if entry.R != nil && entry.G != nil && entry.B != nil {
+				event.Color = &RGB{*entry.R, *entry.G, *entry.B}
+			} else if color, message, ok := parseSpecialColor(entry.Body); ok {
+				event.Color = &color
+				event.Message = message
+			}
+		}
+		event.Priority = 5000 // remind default
+		if entry.Priority != nil { event.Priority = *entry.Priority }
+		event.RawBody = entry.RawBody
+		if event.RawBody == "" { event.RawBody = entry.Body }
+
+		event.TrigDay = entry.D
+		event.TrigMonth = entry.M
+		event.TrigYear = entry.Y
+		event.TrigWeekdays = entry.Wd
+		event.Delta = entry.Delta
+		event.Back = entry.Back
+		event.Rep = entry.Rep
+		event.Until = entry.Until
+		event.NonConstExpr = entry.NonConstExpr != 0
 
 		eventsArr = append(eventsArr, event)
 	}
@@ -124,52 +300,94 @@ func parseRemindEventsJSON(str string) ([]Event, error) {
 // duration: single number, in minutes
 // time: single number, in minutes e.g. 8:30am = 8*60+30
 // body: event message
-//
-// Currently just using date and body
+// Fields that are not set are '*'
 func parseRemindEventSimpleFormat(str string) (Event, error) {
-	dateStr, rest, found := strings.Cut(str, " ") 
-	if !found { return Event{}, fmt.Errorf("Invalid REM Event: %s", str) }
+	fields := strings.SplitN(str, " ", 6)
+	if len(fields) < 6 { return Event{}, fmt.Errorf("Invalid REM Event: %s", str) }
 
-	t, err := time.Parse("2006/01/02", dateStr)
+	t, err := time.Parse("2006/01/02", fields[0])
 	if err != nil { 
-		return Event{}, fmt.Errorf("Could not parse REM Event date %s: %w", str[:10], err) 
+		return Event{}, fmt.Errorf("Could not parse REM Event date %s: %w", fields[0], err) 
 	}
+	event, err := NewEvent(t.Year(), int(t.Month()), t.Day(), fields[5])
+	if err != nil { return event, err }
 
-	// passthru
-	_, rest, found = strings.Cut(rest, " ")
-	if !found { return Event{}, fmt.Errorf("Invalid REM Event: %s", str) }
-
-	// tags
-	_, rest, found = strings.Cut(rest, " ")
-	if !found { return Event{}, fmt.Errorf("Invalid REM Event: %s", str) }
-
-	// duration
-	_, rest, found = strings.Cut(rest, " ")
-	if !found { return Event{}, fmt.Errorf("Invalid REM Event: %s", str) }
-
-	// time
-	_, rest, found = strings.Cut(rest, " ")
-	if !found { return Event{}, fmt.Errorf("Invalid REM Event: %s", str) }
+	if fields[1] != "*" { event.Passthru = fields[1] }
+	if isColorSpecial(event.Passthru) {
+		if color, message, ok := parseSpecialColor(event.Message); ok {
+			event.Color = &color
+			event.Message = message
+		}
+	}
+	event.Tags = parseTags(fields[2])
+	if fields[3] != "*" {
+		if event.Duration, err = strconv.Atoi(fields[3]); err != nil {
+			return event, fmt.Errorf("Invalid REM Event duration %s: %w", fields[3], err)
+		}
+		event.EventDuration = event.Duration
+	}
+	if fields[4] != "*" {
+		if event.Time, err = strconv.Atoi(fields[4]); err != nil {
+			return event, fmt.Errorf("Invalid REM Event time %s: %w", fields[4], err)
+		}
+	}
+	return event, nil
+}
 
-	return NewEvent(t.Year(), int(t.Month()), t.Day(), rest)
+// Splits remind tag field "a,b,c" into its tags, "*" means no tags
+func parseTags(str string) []string {
+	tags := []string{}
+	if str == "" || str == "*" { return tags }
+	for _, tag := range strings.Split(str, ",") {
+		if tag != "" { tags = append(tags, tag) }
+	}
+	return tags
 }
 
 ///////////////// OTHER ////////////////////////////////
-func openEditor(filename string, lineno int) {
+func openEditor(filename string, lineno int) error {
 	editor := os.Getenv("EDITOR")
 	if editor == "" { editor = "vim" }
 
 	// for certain editors jump to lineno as well
 	var cmd *exec.Cmd
-	switch filepath.Base(editor) {
-	case "vi", "vim", "nano", "emacs":
-		cmd = exec.Command(editor, "+" + strconv.Itoa(lineno), filename)
-	default:
-		cmd = exec.Command(editor, filename)
+	if editorTemplate != "" {
+		args := editorArgs(editorTemplate, filename, lineno)
+		editor = args[0]
+		cmd = exec.Command(args[0], args[1:]...)
+	} else {
+		switch filepath.Base(editor) {
+		case "vi", "vim", "nano", "emacs":
+			cmd = exec.Command(editor, "+" + strconv.Itoa(lineno), filename)
+		default:
+			cmd = exec.Command(editor, filename)
+		}
 	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 
+	cmd.Stderr = os.Stderr
+
 	err := cmd.Run()
-	if err != nil { panic(err) }
+	if err != nil { return fmt.Errorf("Editor %s failed: %w", editor, err) }
+	return nil
+}
+
+// Editor command with {file} and {line} placeholders e.g. "code -g {file}:{line}"
+// empty to use $EDITOR
+var editorTemplate = ""
+
+// Splits the template at spaces and replaces the placeholders, the file is
+// appended if the template does not contain {file}
+func editorArgs(template string, filename string, lineno int) []string {
+	args := []string{}
+	hasFile := false
+	for _, field := range strings.Fields(template) {
+		if strings.Contains(field, "{file}") { hasFile = true }
+		field = strings.ReplaceAll(field, "{file}", filename)
+		field = strings.ReplaceAll(field, "{line}", strconv.Itoa(lineno))
+		args = append(args, field)
+	}
+	if !hasFile { args = append(args, filename) }
+	return args
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// testEventsUnicode.json is the output of
+//	remind -ppp12 -g testEventsUnicode.rem 2026-01-01
+// go test -update rewrites it with the installed remind
+var updateFixtures = flag.Bool("update", false, "rewrite testEventsUnicode.json with the output of the installed remind")
+
+type fixtureReminder struct {
+	date Date
+	time int // minutes, -1 for untimed reminders
+	message string
+	lineno int
+}
+
+var fixtureLineRegex = regexp.MustCompile(`^REM (\w+) (\d+)(?: AT (\d+):(\d+))? MSG (.*)$`)
+
+// Reads the reminders of testEventsUnicode.rem, placed in 2026
+func readUnicodeFixture(t *testing.T) []fixtureReminder {
+	f, err := os.Open("testEventsUnicode.rem")
+	if err != nil { t.Fatal(err) }
+	defer f.Close()
+
+	reminders := []fixtureReminder{}
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		m := fixtureLineRegex.FindStringSubmatch(scanner.Text())
+		if m == nil { t.Fatalf("testEventsUnicode.rem:%d: unexpected line %q", lineno, scanner.Text()) }
+		month, err := time.Parse("January", m[1])
+		if err != nil { t.Fatal(err) }
+		day, _ := strconv.Atoi(m[2])
+		d, err := NewDate(2026, int(month.Month()), day)
+		if err != nil { t.Fatal(err) }
+		r := fixtureReminder{d, -1, m[5], lineno}
+		if m[3] != "" {
+			hour, _ := strconv.Atoi(m[3])
+			minute, _ := strconv.Atoi(m[4])
+			r.time = hour*60 + minute
+		}
+		reminders = append(reminders, r)
+	}
+	if err := scanner.Err(); err != nil { t.Fatal(err) }
+	if len(reminders) == 0 { t.Fatal("testEventsUnicode.rem is empty") }
+	return reminders
+}
+
+func unicodeFixtureOutput(t *testing.T) string {
+	if *updateFixtures {
+		out, err := runRemind("-ppp12", "-g", "testEventsUnicode.rem", "2026-01-01")
+		if err != nil { t.Fatal(err) }
+		if err := os.WriteFile("testEventsUnicode.json", []byte(out), 0644); err != nil { t.Fatal(err) }
+	}
+	content, err := os.ReadFile("testEventsUnicode.json")
+	if err != nil { t.Fatal(err) }
+	return string(content)
+}
+
+// Events of the captured remind output by day
+func unicodeFixtureEvents(t *testing.T) map[string][]Event {
+	eventsArr, err := parseRemindEventsJSON(unicodeFixtureOutput(t))
+	if err != nil { t.Fatal(err) }
+	events := map[string][]Event{}
+	for _, e := range eventsArr { addEvent(e, events) }
+	sortEvents(events)
+	return events
+}
+
+func TestParseRemindEventsJSONUnicode(t *testing.T) {
+	reminders := readUnicodeFixture(t)
+	eventsArr, err := parseRemindEventsJSON(unicodeFixtureOutput(t))
+	if err != nil { t.Fatal(err) }
+	if len(eventsArr) != len(reminders) { t.Fatalf("parsed %d events, want %d", len(eventsArr), len(reminders)) }
+	for _, e := range eventsArr {
+		if e.Lineno < 1 || e.Lineno > len(reminders) { t.Errorf("unexpected line %d", e.Lineno); continue }
+		r := reminders[e.Lineno-1]
+		if e.Date != r.date { t.Errorf("line %d: date %s, want %s", e.Lineno, remDate(e.Date), remDate(r.date)) }
+		if !utf8.ValidString(e.Message) { t.Errorf("line %d: invalid UTF-8 in %q", e.Lineno, e.Message) }
+		if e.Title() != r.message { t.Errorf("line %d: title %q, want %q", e.Lineno, e.Title(), r.message) }
+		if e.RawBody != r.message { t.Errorf("line %d: raw body %q, want %q", e.Lineno, e.RawBody, r.message) }
+		if e.Time != r.time { t.Errorf("line %d: time %d, want %d", e.Lineno, e.Time, r.time) }
+		if e.TrigDay != r.date.Day || e.TrigMonth != r.date.Month || e.TrigYear != 0 {
+			t.Errorf("line %d: trigger %d %d %d", e.Lineno, e.TrigDay, e.TrigMonth, e.TrigYear)
+		}
+	}
+}
+
+// The installed remind still produces the captured output
+func TestRemindUnicodeFixture(t *testing.T) {
+	if _, err := exec.LookPath(remindCommand); err != nil { t.Skip("remind is not installed") }
+	eventsArr, err := getEvents("testEventsUnicode.rem", 2026, 1, 12)
+	if err != nil { t.Fatal(err) }
+	captured, err := parseRemindEventsJSON(unicodeFixtureOutput(t))
+	if err != nil { t.Fatal(err) }
+	if !reflect.DeepEqual(eventsArr, captured) { t.Errorf("remind output differs from testEventsUnicode.json, see go test -update") }
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Search over messages, tags and source files of all events in a window of years
+// around the selected date. Plain queries match substrings ( case insensitive unless
+// the query contains upper case letters ), regex queries use Go regexp syntax
+// Years before and after the year the search started in that are searched ( search_years )
+var searchYears = 1
+
+type Search struct {
+	Query string
+	Regex bool
+	Active bool // results pane is shown
+	Hits []Event
+	Current int // index into Hits, -1 if none selected
+	Err string
+}
+
+func (s *Search) matcher() (func(string) bool, error) {
+	if s.Regex {
+		re, err := regexp.Compile(s.Query)
+		if err != nil { return nil, err }
+		return re.MatchString, nil
+	}
+	query := s.Query
+	ignoreCase := strings.IndexFunc(query, unicode.IsUpper) < 0
+	if ignoreCase { query = strings.ToLower(query) }
+	return func(text string) bool {
+		if ignoreCase { text = strings.ToLower(text) }
+		return strings.Contains(text, query)
+	}, nil
+}
+
+// Updates Hits for the current query, events must be sorted by date
+func (s *Search) Run(events []Event) {
+	s.Hits = []Event{}
+	s.Current = -1
+	s.Err = ""
+	if s.Query == "" { return }
+	match, err := s.matcher()
+	if err != nil { s.Err = err.Error(); return }
+	for _, e := range events {
+		if match(e.Message) || match(strings.Join(e.Tags, ",")) || match(e.Filename) {
+			s.Hits = append(s.Hits, e)
+		}
+	}
+}
+
+// Selects the next hit after d ( or the previous hit before d if forward is false )
+func (s *Search) Jump(d Date, forward bool) (Event, bool) {
+	if len(s.Hits) == 0 { return Event{}, false }
+	if s.Current >= 0 && s.Current < len(s.Hits) && s.Hits[s.Current].Date == d {
+		// continue from the current hit, several hits can share a day
+		if forward { s.Current++ } else { s.Current-- }
+	} else if forward {
+		s.Current = sort.Search(len(s.Hits), func(i int) bool { return dateBefore(d, s.Hits[i].Date) })
+	} else {
+		s.Current = sort.Search(len(s.Hits), func(i int) bool { return !dateBefore(s.Hits[i].Date, d) }) - 1
+	}
+	// wrap around
+	if s.Current >= len(s.Hits) { s.Current = 0 }
+	if s.Current < 0 { s.Current = len(s.Hits)-1 }
+	return s.Hits[s.Current], true
+}
+
+// Events of a larger date range loaded in the background for searching
+// Reloaded when the range or the loader generation changes
+type SearchIndex struct {
+	mu sync.Mutex
+	key string
+	events []Event
+	version int // increased whenever loading finished
+	loading bool
+}
+
+// Returns the events of nrOfMonth months starting at year, month sorted by date
+// ready is false while remind is still running, version changes with every reload
+func (si *SearchIndex) Events(filename string, year int, month int, nrOfMonth int, gen int) (events []Event, version int, ready bool) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	key := fmt.Sprintf("%d-%d+%d@%d", year, month, nrOfMonth, gen)
+	if si.key == key { return si.events, si.version, !si.loading }
+
+	si.key = key
+	si.loading = true
+	go func() {
+		// on remind problems partial results are still searchable
+		eventsArr, _ := getEvents(filename, year, month, nrOfMonth)
+		sort.SliceStable(eventsArr, func(i, j int) bool { return dateBefore(eventsArr[i].Date, eventsArr[j].Date) })
+
+		si.mu.Lock()
+		defer si.mu.Unlock()
+		if si.key != key { return } // outdated
+		si.loading = false
+		si.events = eventsArr
+		si.version++
+	}()
+	return si.events, si.version, false
+}
+
+// Reads the search query in the status line, update is called after every key
+// so the results follow the typing. Returns false if canceled with ESC
+// CTRL-R toggles between plain and regex search
+func runSearchPrompt(win *Window, width int, s *Search, update func()) bool {
+	defer Curs_set(0)
+	Curs_set(1)
+	for {
+		update()
+
+		prefix := "/"
+		if s.Regex { prefix = "regex/" }
+		status := fmt.Sprintf("%d hits", len(s.Hits))
+		if s.Err != "" { status = s.Err }
+		status = "  ( " + status + ", CTRL-R:Regex  Enter:Done  ESC:Cancel )"
+
+		Wattron(win, COLOR_PAIR(5))
+		Mvwhline(win, 0, 0, ' ', width)
+		line := prefix + s.Query
+		Mvwprintw(win, 0, 1, trimMessage(line + status, width-2))
+		Wattroff(win, COLOR_PAIR(5))
+		cursorX := 1 + stringWidth(line)
+		if cursorX > width-1 { cursorX = width-1 }
+		win.Move(0, cursorX)
+		win.Refresh()
+
+		c := Getch()
+		switch(c) {
+			case 27: // ESC
+				return false
+			case '\n', '\r', KEY_ENTER:
+				return true
+			case 18: // CTRL-R
+				s.Regex = !s.Regex
+			case KEY_BACKSPACE, 127, 8:
+				runes := []rune(s.Query)
+				if len(runes) > 0 { s.Query = string(runes[:len(runes)-1]) }
+			case 21: // CTRL-U
+				s.Query = ""
+			case -1: // skip ERR ( see halfdelay ), results may have finished loading
+			default:
+				if c >= 32 && c < 256 { s.Query = string(append([]byte(s.Query), byte(c))) }
+		}
+	}
+}
+
+// Lists search hits with their dates, the current hit is highlighted
+func drawSearchResults(win *Window, h int, w int, y int, x int, active bool, s *Search, loading bool) {
+	if active { Wattron(win, COLOR_PAIR(1)) }
+	drawBox(win, h, w, y, x)
+	Wattroff(win, COLOR_PAIR(1))
+
+	title := fmt.Sprintf(" Search %q: %d hits ", s.Query, len(s.Hits))
+	if loading { title = fmt.Sprintf(" Search %q: loading... ", s.Query) }
+	Wattron(win, COLOR_PAIR(1))
+	Mvwprintw(win, y, x+2, trimMessage(title, w-4))
+	Wattroff(win, COLOR_PAIR(1))
+
+	rows := h-2
+	if rows < 1 { return }
+	offset := 0
+	if s.Current >= rows { offset = s.Current - rows + 1 }
+	for i := offset; i < len(s.Hits) && i-offset < rows; i++ {
+		e := s.Hits[i]
+		source := ""
+		if e.Filename != "" { source = fmt.Sprintf("  %s:%d", filepath.Base(e.Filename), e.Lineno) }
+		tags := ""
+		if len(e.Tags) > 0 { tags = "  [" + strings.Join(e.Tags, ",") + "]" }
+		line := fmt.Sprintf("%s  %s%s%s", remDate(e.Date), e.Message, tags, source)
+
+		attrs := 0
+		if i == s.Current { attrs = COLOR_PAIR(1) | A_BOLD }
+		Wattron(win, attrs)
+		Mvwprintw(win, y+1+i-offset, x+2, trimMessage(line, w-4))
+		Wattroff(win, attrs)
+	}
+}
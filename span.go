@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Multi-day events: a bounded REM line ( THROUGH or UNTIL ) that triggers on
+// consecutive days is shown as one span instead of separate events
+
+type spanKey struct {
+	filename string
+	lineno int
+}
+
+type spanRef struct {
+	key string // day of events
+	index int
+	date Date
+}
+
+// Sets SpanDay and SpanDays of untimed events that repeat on consecutive days
+// Events are loaded in whole months, a span reaching the first or last day of
+// them is completed from the trigger date and UNTIL of its REM line ( see spanBounds )
+func markSpans(events map[string][]Event) {
+	groups := map[spanKey][]spanRef{}
+	for key, dayEvents := range events {
+		for i, e := range dayEvents {
+			if e.IsTimed() || e.Until == "" || e.Filename == "" { continue }
+			k := spanKey{e.Filename, e.Lineno}
+			groups[k] = append(groups[k], spanRef{key, i, e.Date})
+		}
+	}
+	for _, refs := range groups {
+		sort.Slice(refs, func(i, j int) bool { return dateBefore(refs[i].date, refs[j].date) })
+		start := 0
+		for i := 1; i <= len(refs); i++ {
+			if i < len(refs) {
+				next := refs[i-1].date
+				next.AddDay()
+				if next == refs[i].date { continue }
+			}
+			// refs[start:i] are consecutive days
+			first, last := refs[start].date, refs[i-1].date
+			if bFirst, bLast, ok := spanBounds(events[refs[start].key][refs[start].index]); ok {
+				if first.Day == 1 && dateBefore(bFirst, first) { first = bFirst }
+				if last.Day == DaysInMonth(last.Year, time.Month(last.Month)) && dateBefore(last, bLast) { last = bLast }
+			}
+			if days := daysBetween(first, last)+1; days > 1 {
+				for _, ref := range refs[start:i] {
+					events[ref.key][ref.index].SpanDay = daysBetween(first, ref.date)+1
+					events[ref.key][ref.index].SpanDays = days
+				}
+			}
+			start = i
+		}
+	}
+}
+
+// First and last day of a bounded reminder as written in its REM line, a full trigger
+// date and UNTIL ( THROUGH ). ok is false if either is unknown e.g. computed by an expression
+func spanBounds(e Event) (first Date, last Date, ok bool) {
+	if e.Rep != 1 || e.NonConstExpr || e.TrigYear == 0 || e.TrigMonth == 0 || e.TrigDay == 0 { return }
+	first, err := NewDate(e.TrigYear, e.TrigMonth, e.TrigDay)
+	if err != nil { return }
+	last, err = parseISODate(e.Until)
+	if err != nil || dateBefore(last, first) { return }
+	return first, last, true
+}
+
+func (e *Event) InSpan() bool {
+	return e.SpanDays > 1
+}
+
+// "day 3 of 15", empty for events that are not part of a span
+func spanLabel(e Event) string {
+	if !e.InSpan() { return "" }
+	return fmt.Sprintf("day %d of %d", e.SpanDay, e.SpanDays)
+}
+
+// How a day of the calendar widget takes part in spans
+type spanMark struct {
+	in bool
+	prev bool // a span continues from the day before
+	next bool // a span continues on the next day
+}
+
+func daySpanMark(dayEvents []Event) (mark spanMark) {
+	for _, e := range dayEvents {
+		if !e.InSpan() { continue }
+		mark.in = true
+		if e.SpanDay > 1 { mark.prev = true }
+		if e.SpanDay < e.SpanDays { mark.next = true }
+	}
+	return
+}
+
+// Rows of the all day strip of the week view for the untimed events of days
+// A span keeps its row on all days so it is drawn as a continuous bar,
+// other events fill the free rows of their day. The result holds the row per event index
+func allDayLanes(dayEvents [7][]Event) (lanes [7]map[int]int, rows int) {
+	spanLanes := map[spanKey]int{}
+	for i := range dayEvents {
+		lanes[i] = map[int]int{}
+		used := map[int]bool{}
+		// continued spans first, they already have a row
+		for ei, e := range dayEvents[i] {
+			if e.IsTimed() || !e.InSpan() { continue }
+			if lane, ok := spanLanes[spanKey{e.Filename, e.Lineno}]; ok && e.SpanDay > 1 {
+				lanes[i][ei] = lane
+				used[lane] = true
+			}
+		}
+		for ei, e := range dayEvents[i] {
+			if e.IsTimed() { continue }
+			if _, ok := lanes[i][ei]; ok { continue }
+			lane := 0
+			for used[lane] { lane++ }
+			lanes[i][ei] = lane
+			used[lane] = true
+			if e.InSpan() { spanLanes[spanKey{e.Filename, e.Lineno}] = lane }
+		}
+		for lane := range used {
+			if lane+1 > rows { rows = lane+1 }
+		}
+	}
+	return
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+// THROUGH reminder from first to last as remind reports it for the days of from to to
+func throughEvents(t *testing.T, first string, last string, from string, to string) map[string][]Event {
+	firstDate, err := parseISODate(first)
+	if err != nil { t.Fatal(err) }
+	d, err := parseISODate(from)
+	if err != nil { t.Fatal(err) }
+	end, err := parseISODate(to)
+	if err != nil { t.Fatal(err) }
+	events := map[string][]Event{}
+	for ; !dateBefore(end, d); d.AddDay() {
+		e, err := NewEvent(d.Year, d.Month, d.Day, "Trip")
+		if err != nil { t.Fatal(err) }
+		e.Filename, e.Lineno = "trip.rem", 1
+		e.TrigYear, e.TrigMonth, e.TrigDay = firstDate.Year, firstDate.Month, firstDate.Day
+		e.Rep, e.Until = 1, last
+		addEvent(e, events)
+	}
+	return events
+}
+
+func TestMarkSpans(t *testing.T) {
+	tests := []struct {
+		first string
+		last string
+		from string // loaded days of the span
+		to string
+		day string
+		wantDay int
+		wantDays int
+	}{
+		{"2026-10-14", "2026-10-28", "2026-10-14", "2026-10-28", "2026-10-17", 4, 15},
+		// starts before the loaded months
+		{"2026-09-20", "2026-10-10", "2026-10-01", "2026-10-10", "2026-10-01", 12, 21},
+		{"2026-09-30", "2026-10-10", "2026-10-01", "2026-10-10", "2026-10-10", 11, 11},
+		// ends after them
+		{"2026-10-25", "2026-11-10", "2026-10-25", "2026-10-31", "2026-10-31", 7, 17},
+		// only a single day is loaded
+		{"2026-09-20", "2026-11-10", "2026-10-01", "2026-10-31", "2026-10-01", 12, 52},
+		{"2026-10-31", "2026-11-10", "2026-10-31", "2026-10-31", "2026-10-31", 1, 11},
+	}
+	for _, test := range tests {
+		events := throughEvents(t, test.first, test.last, test.from, test.to)
+		markSpans(events)
+		d, _ := parseISODate(test.day)
+		e := events[d.NumericString()][0]
+		if e.SpanDay != test.wantDay || e.SpanDays != test.wantDays {
+			t.Errorf("%s to %s loaded from %s: %s is day %d of %d, want %d of %d",
+				test.first, test.last, test.from, test.day, e.SpanDay, e.SpanDays, test.wantDay, test.wantDays)
+		}
+	}
+}
+
+func TestMarkSpansUnknownBounds(t *testing.T) {
+	// the trigger is computed, the span is only numbered within the loaded days
+	events := throughEvents(t, "2026-09-20", "2026-10-10", "2026-10-01", "2026-10-03")
+	for key := range events { events[key][0].NonConstExpr = true }
+	markSpans(events)
+	d, _ := parseISODate("2026-10-02")
+	if e := events[d.NumericString()][0]; e.SpanDay != 2 || e.SpanDays != 3 {
+		t.Errorf("day %d of %d, want 2 of 3", e.SpanDay, e.SpanDays)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Out of band data of SPECIAL reminders, remind passes it in the passthru field
+// COLOR reminders are normal events drawn in their color, SHADE and MOON reminders
+// describe the day itself and are shown in the calendar widget instead of the events list
+
+type RGB struct {
+	R int
+	G int
+	B int
+}
+
+// Per day data of SHADE and MOON reminders
+type DaySpecial struct {
+	Shade *RGB
+	Moon int // phase 0 new, 1 first quarter, 2 full, 3 last quarter, -1 none
+}
+
+var moonGlyphs = []string{"●", "◐", "○", "◑"}
+
+func isColorSpecial(passthru string) bool {
+	return strings.EqualFold(passthru, "COLOR") || strings.EqualFold(passthru, "COLOUR")
+}
+
+// SHADE and MOON reminders are not shown as events
+func (e *Event) IsDaySpecial() bool {
+	return strings.EqualFold(e.Passthru, "SHADE") || strings.EqualFold(e.Passthru, "MOON")
+}
+
+// Splits "r g b message" as written after SPECIAL COLOR, ok is false if body does not start with a color
+func parseSpecialColor(body string) (color RGB, message string, ok bool) {
+	fields := strings.SplitN(strings.TrimSpace(body), " ", 4)
+	if len(fields) < 3 { return color, body, false }
+	values := [3]int{}
+	for i := range values {
+		v, err := strconv.Atoi(fields[i])
+		if err != nil || v < 0 || v > 255 { return color, body, false }
+		values[i] = v
+	}
+	if len(fields) == 4 { message = strings.TrimSpace(fields[3]) }
+	return RGB{values[0], values[1], values[2]}, message, true
+}
+
+// SHADE takes either "r g b" or a single gray level "n"
+func parseShade(body string) (RGB, bool) {
+	if color, _, ok := parseSpecialColor(body); ok { return color, true }
+	fields := strings.Fields(body)
+	if len(fields) == 0 { return RGB{}, false }
+	v, err := strconv.Atoi(fields[0])
+	if err != nil || v < 0 || v > 255 { return RGB{}, false }
+	return RGB{v, v, v}, true
+}
+
+// MOON bodies are "phase [size [fontsize [message]]]"
+func parseMoonPhase(body string) int {
+	fields := strings.Fields(body)
+	if len(fields) == 0 { return -1 }
+	phase, err := strconv.Atoi(fields[0])
+	if err != nil || phase < 0 || phase > 3 { return -1 }
+	return phase
+}
+
+// Removes SHADE and MOON reminders from events and returns them per day
+func splitSpecials(events map[string][]Event) (map[string][]Event, map[string]DaySpecial) {
+	specials := map[string]DaySpecial{}
+	filtered := map[string][]Event{}
+	for key, dayEvents := range events {
+		for _, e := range dayEvents {
+			if !e.IsDaySpecial() {
+				filtered[key] = append(filtered[key], e)
+				continue
+			}
+			special, ok := specials[key]
+			if !ok { special.Moon = -1 }
+			if strings.EqualFold(e.Passthru, "SHADE") {
+				if shade, ok := parseShade(e.Message); ok { special.Shade = &shade }
+			} else if phase := parseMoonPhase(e.Message); phase >= 0 {
+				special.Moon = phase
+			}
+			specials[key] = special
+		}
+	}
+	return filtered, specials
+}
+
+///////////////// COLORS ////////////////////////////////
+
+// Color pairs for SPECIAL colors are allocated on demand after the fixed pairs
+const SPECIAL_PAIR_START = 64
+const SPECIAL_PAIR_END = 255 // COLOR_PAIR attributes hold 8 bits
+
+type colorPairKey struct {
+	fg int
+	bg int
+}
+
+var specialPairs = map[colorPairKey]int{}
+
+// Color pair attribute for a foreground and background color ( -1 for the default )
+// returns 0 if all pairs are in use
+func specialColorPair(fg int, bg int) int {
+	key := colorPairKey{fg, bg}
+	pair, ok := specialPairs[key]
+	if !ok {
+		pair = SPECIAL_PAIR_START + len(specialPairs)
+		if pair > SPECIAL_PAIR_END { return 0 }
+		Init_extended_pair(pair, fg, bg)
+		specialPairs[key] = pair
+	}
+	return COLOR_PAIR(pair)
+}
+
+// Attribute to draw the message of a SPECIAL COLOR event, 0 for other events
+func specialAttrs(e Event) int {
+	if e.Color == nil { return 0 }
+	return specialColorPair(terminalColor(*e.Color), -1)
+}
+
+// SPECIAL COLOR takes precedence over tag colors
+func eventAttrs(e Event) int {
+	if e.Color != nil { return specialAttrs(e) }
+	return tagAttrs(e)
+}
+
+// Foreground of the events and today colors ( see Config.Apply ), shaded days keep it
+var eventsFg = COLOR_CYAN
+var todayFg = COLOR_YELLOW
+
+// Attribute to tint a day cell of the calendar drawn in fg, 0 if the day is not shaded
+func shadeAttrs(special DaySpecial, fg int) int {
+	if special.Shade == nil { return 0 }
+	return specialColorPair(fg, terminalColor(*special.Shade))
+}
+
+// The terminal color closest to c, true color if the terminal supports direct colors
+func terminalColor(c RGB) int {
+	colors := COLORS()
+	switch {
+	case colors >= 1<<24:
+		return c.R<<16 | c.G<<8 | c.B
+	case colors >= 256:
+		return nearestXterm256(c)
+	case colors >= 16:
+		return nearestColor(c, ansiColors[:16])
+	default:
+		return nearestColor(c, ansiColors[:8])
+	}
+}
+
+// xterm default palette of the 16 ansi colors
+var ansiColors = []RGB{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+func nearestColor(c RGB, palette []RGB) int {
+	best, bestDist := 0, -1
+	for i, p := range palette {
+		dist := colorDistance(c, p)
+		if bestDist < 0 || dist < bestDist { best, bestDist = i, dist }
+	}
+	return best
+}
+
+// 256 color terminals have a 6x6x6 color cube at 16 and 24 grays at 232
+func nearestXterm256(c RGB) int {
+	levels := []int{0, 95, 135, 175, 215, 255}
+	nearestLevel := func(v int) int {
+		best := 0
+		for i, l := range levels {
+			if abs(v-l) < abs(v-levels[best]) { best = i }
+		}
+		return best
+	}
+	r, g, b := nearestLevel(c.R), nearestLevel(c.G), nearestLevel(c.B)
+	cube := RGB{levels[r], levels[g], levels[b]}
+	cubeIndex := 16 + 36*r + 6*g + b
+
+	grayLevel := (c.R + c.G + c.B) / 3
+	grayIndex := (grayLevel - 8) / 10
+	if grayIndex < 0 { grayIndex = 0 }
+	if grayIndex > 23 { grayIndex = 23 }
+	gray := 8 + grayIndex*10
+
+	if colorDistance(c, RGB{gray, gray, gray}) < colorDistance(c, cube) { return 232 + grayIndex }
+	return cubeIndex
+}
+
+func colorDistance(a RGB, b RGB) int {
+	dr, dg, db := a.R-b.R, a.G-b.G, a.B-b.B
+	return dr*dr + dg*dg + db*db
+}
+
+func abs(x int) int {
+	if x < 0 { return -x }
+	return x
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// Filters events by their remind TAGs
+// Mode "only" keeps events with at least one of Tags, "hide" removes them
+type TagFilter struct {
+	Mode string
+	Tags []string
+}
+
+var tagFilterModes = []string{"off", "only", "hide"}
+
+func (f TagFilter) Enabled() bool {
+	return f.Mode != "" && f.Mode != "off" && len(f.Tags) > 0
+}
+
+func (f TagFilter) Allows(e Event) bool {
+	if !f.Enabled() { return true }
+	hasTag := false
+	for _, tag := range f.Tags {
+		if e.HasTag(tag) { hasTag = true; break }
+	}
+	if f.Mode == "only" { return hasTag }
+	return !hasTag
+}
+
+// Returns events without the ones the filter rejects, days without events are dropped
+func (f TagFilter) Apply(events map[string][]Event) map[string][]Event {
+	if !f.Enabled() { return events }
+	filtered := map[string][]Event{}
+	for key, dayEvents := range events {
+		for _, e := range dayEvents {
+			if f.Allows(e) { filtered[key] = append(filtered[key], e) }
+		}
+	}
+	return filtered
+}
+
+// Short description for window titles e.g. "only: work,family"
+func (f TagFilter) String() string {
+	if !f.Enabled() { return "" }
+	return f.Mode + ": " + strings.Join(f.Tags, ",")
+}
+
+// splits a comma or space separated tag list
+func parseTagList(str string) []string {
+	return strings.FieldsFunc(str, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+func tagFilterForm(f TagFilter) *Form {
+	mode := f.Mode
+	if mode == "" { mode = "off" }
+	return &Form{
+		Title: "Filter by Tag",
+		Fields: []FormField{
+			{Label: "Mode", Value: mode, Options: tagFilterModes},
+			{Label: "Tags", Value: strings.Join(f.Tags, ",")},
+		},
+		selected: 1,
+	}
+}
+
+///////////////// COLORS ////////////////////////////////
+
+// Color pairs used for tagged events, initialized in DrawingLoop
+const TAG_PAIR_START = 10
+var tagPalette = []int{COLOR_RED, COLOR_GREEN, COLOR_YELLOW, COLOR_BLUE, COLOR_MAGENTA, COLOR_CYAN}
+
+// Explicit tag to color assignments ( curses color numbers ), tags without
+// an entry get a color from tagPalette based on their name so they stay stable
+var tagColors = map[string]ColorSpec{}
+
+func initTagColors() {
+	for i, color := range tagPalette {
+		Init_pair(TAG_PAIR_START+i, color, -1)
+	}
+	for i, tag := range sortedTagColors() {
+		Init_pair(TAG_PAIR_START+len(tagPalette)+i, tagColors[tag].Fg, tagColors[tag].Bg)
+	}
+}
+
+// explicit colors get the pairs after the palette in sorted order
+func sortedTagColors() []string {
+	tags := []string{}
+	for tag := range tagColors { tags = append(tags, tag) }
+	sort.Strings(tags)
+	return tags
+}
+
+// Color pair attribute for e, 0 for untagged events
+func tagAttrs(e Event) int {
+	if len(e.Tags) == 0 { return 0 }
+	return COLOR_PAIR(tagPair(e.Tags[0]))
+}
+
+func tagPair(tag string) int {
+	tag = strings.ToLower(tag)
+	for i, t := range sortedTagColors() {
+		if strings.ToLower(t) == tag { return TAG_PAIR_START+len(tagPalette)+i }
+	}
+	h := fnv.New32a()
+	h.Write([]byte(tag))
+	return TAG_PAIR_START + int(h.Sum32() % uint32(len(tagPalette)))
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Watches the reminder files for changes made outside of remindcal
+// Tracked are the top level file ( or all *.rem files if it is a directory ),
+// every file remind reported events from and files pulled in via INCLUDE/DO.
+// inotify is used where available ( see watcher_linux.go ) otherwise
+// the files are polled every pollInterval
+type FileWatcher struct {
+	root string
+	rootIsDir bool
+
+	mu sync.Mutex
+	files map[string]bool
+	reported []string // files remind reported events from, see SetFiles
+	includes []string // files included by root, see scanIncludes
+	backend watchBackend // nil if polling
+	dirs map[string]bool // directories added to backend
+
+	changes chan string
+	done chan struct{}
+}
+
+// Platform specific notification of changes inside of directories
+type watchBackend interface {
+	Add(dir string) error
+	Close()
+}
+
+const pollInterval = 2 * time.Second
+
+func NewFileWatcher(root string) *FileWatcher {
+	w := &FileWatcher{
+		files: map[string]bool{},
+		dirs: map[string]bool{},
+		changes: make(chan string, 1),
+		done: make(chan struct{}),
+	}
+	w.root, _ = filepath.Abs(root)
+	if info, err := os.Stat(w.root); err == nil && info.IsDir() { w.rootIsDir = true }
+
+	backend, err := newNotifyBackend(w.notify)
+	if err == nil {
+		w.backend = backend
+	} else {
+		go w.poll()
+	}
+	w.SetFiles(nil)
+	go w.scanIncludes()
+	return w
+}
+
+// Changed file path, receives at most one pending change
+func (w *FileWatcher) Changes() <-chan string {
+	return w.changes
+}
+
+// Replaces the files remind reported events from, root and its includes are always tracked
+// This does not touch the disk, it is called whenever events are loaded
+func (w *FileWatcher) SetFiles(files []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.reported = files
+	w.update()
+}
+
+// Rescans the include tree of root, runs in the background at start and after
+// every change since the change may have added or removed INCLUDE lines
+func (w *FileWatcher) scanIncludes() {
+	includes := findIncludes(w.root)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.includes = includes
+	w.update()
+}
+
+// Rebuilds the tracked files and watches their directories, w.mu must be held
+func (w *FileWatcher) update() {
+	files := append([]string{w.root}, w.reported...)
+	files = append(files, w.includes...)
+	w.files = map[string]bool{}
+	for _, f := range files {
+		if abs, err := filepath.Abs(f); err == nil { w.files[abs] = true }
+	}
+	if w.backend == nil { return }
+
+	dirs := []string{}
+	if w.rootIsDir { dirs = append(dirs, w.root) }
+	for f := range w.files {
+		dirs = append(dirs, filepath.Dir(f))
+	}
+	for _, dir := range dirs {
+		if w.dirs[dir] { continue }
+		if err := w.backend.Add(dir); err == nil { w.dirs[dir] = true }
+	}
+}
+
+func (w *FileWatcher) Close() {
+	close(w.done)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.backend != nil { w.backend.Close() }
+}
+
+// called by the backend for every change inside of a watched directory
+func (w *FileWatcher) notify(path string) {
+	w.mu.Lock()
+	tracked := w.files[path] || w.isRootFile(path)
+	w.mu.Unlock()
+	if !tracked { return }
+	w.scanIncludes()
+	w.send(path)
+}
+
+func (w *FileWatcher) send(path string) {
+	select {
+	case w.changes <- path:
+	default: // a change is already pending
+	}
+}
+
+func (w *FileWatcher) isRootFile(path string) bool {
+	return w.rootIsDir && filepath.Dir(path) == w.root && strings.HasSuffix(path, ".rem")
+}
+
+// Polling fallback compares modification time and size of all tracked files
+func (w *FileWatcher) poll() {
+	type fileState struct {
+		modTime time.Time
+		size int64
+	}
+	snapshot := func() map[string]fileState {
+		w.mu.Lock()
+		paths := []string{}
+		for f := range w.files { paths = append(paths, f) }
+		w.mu.Unlock()
+		if w.rootIsDir {
+			remFiles, _ := filepath.Glob(filepath.Join(w.root, "*.rem"))
+			paths = append(paths, remFiles...)
+		}
+
+		states := map[string]fileState{}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil { continue }
+			states[path] = fileState{info.ModTime(), info.Size()}
+		}
+		return states
+	}
+
+	prev := snapshot()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+		cur := snapshot()
+		changed := ""
+		for path, state := range cur {
+			if old, ok := prev[path]; !ok || old != state { changed = path }
+		}
+		for path := range prev {
+			if _, ok := cur[path]; !ok { changed = path }
+		}
+		if changed != "" {
+			w.scanIncludes()
+			w.send(changed)
+		}
+		prev = cur
+	}
+}
+
+// matches INCLUDE and DO lines with a literal path ( no [expressions] )
+var includeRegex = regexp.MustCompile(`(?i)^\s*(INCLUDE|DO)\s+("[^"]+"|[^\s\[]+)\s*$`)
+
+// Scans filename ( a file or directory of *.rem files ) recursively for included files
+// INCLUDE paths are relative to the working directory, DO paths to the including file
+func findIncludes(filename string) []string {
+	includes := []string{}
+	visited := map[string]bool{}
+
+	var scan func(filename string)
+	scan = func(filename string) {
+		filename, err := filepath.Abs(filename)
+		if err != nil || visited[filename] { return }
+		visited[filename] = true
+
+		info, err := os.Stat(filename)
+		if err != nil { return }
+		if info.IsDir() {
+			remFiles, _ := filepath.Glob(filepath.Join(filename, "*.rem"))
+			for _, f := range remFiles { scan(f) }
+			return
+		}
+
+		f, err := os.Open(filename)
+		if err != nil { return }
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			m := includeRegex.FindStringSubmatch(scanner.Text())
+			if m == nil { continue }
+			path := strings.Trim(m[2], `"`)
+			if strings.EqualFold(m[1], "DO") && !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(filename), path)
+			}
+			includes = append(includes, path)
+			scan(path)
+		}
+	}
+	scan(filename)
+	return includes
+}
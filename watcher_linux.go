@@ -0,0 +1,74 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotify backend watching directories, watching the directories instead of
+// the files themselves also catches editors that replace files on save
+type inotifyBackend struct {
+	fd int
+	file *os.File // used for reading only, file.Fd() would make fd blocking
+	mu sync.Mutex
+	dirs map[int32]string
+	onChange func(path string)
+}
+
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM |
+	syscall.IN_CREATE | syscall.IN_DELETE
+
+func newNotifyBackend(onChange func(path string)) (watchBackend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil { return nil, err }
+
+	b := &inotifyBackend{
+		fd: fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: map[int32]string{},
+		onChange: onChange,
+	}
+	go b.read()
+	return b, nil
+}
+
+func (b *inotifyBackend) Add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(b.fd, dir, inotifyMask)
+	if err != nil { return err }
+	b.mu.Lock()
+	b.dirs[int32(wd)] = dir
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *inotifyBackend) Close() {
+	b.file.Close()
+}
+
+func (b *inotifyBackend) read() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := b.file.Read(buf)
+		if err != nil { return } // closed
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			name := string(nameBytes)
+			for len(name) > 0 && name[len(name)-1] == 0 { name = name[:len(name)-1] }
+			if name == "" { continue }
+
+			b.mu.Lock()
+			dir, ok := b.dirs[event.Wd]
+			b.mu.Unlock()
+			if ok { b.onChange(filepath.Join(dir, name)) }
+		}
+	}
+}
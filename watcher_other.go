@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+)
+
+// only inotify is supported, other platforms use polling
+func newNotifyBackend(onChange func(path string)) (watchBackend, error) {
+	return nil, errors.New("file notifications not supported on this platform")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// First day of the week in the calendar widget and the week view
+// Remind itself starts its calendars on Sunday unless called with -m
+var firstWeekday = time.Monday
+
+var weekStartNames = map[string]time.Weekday{
+	"sunday": time.Sunday,
+	"monday": time.Monday,
+	"saturday": time.Saturday,
+}
+
+func parseWeekStart(str string) (time.Weekday, error) {
+	wd, ok := weekStartNames[strings.ToLower(str)]
+	if !ok { return 0, fmt.Errorf("invalid week start %q ( sunday, monday or saturday )", str) }
+	return wd, nil
+}
+
+// Arguments so remind agrees on the first weekday
+func weekStartRemindArgs() []string {
+	if firstWeekday == time.Monday { return []string{"-m"} }
+	return []string{}
+}
+
+// Returns the first day of the week containing d for weeks starting on first
+func weekStart(d Date, first time.Weekday) Date {
+	for i := (Weekday(d.Year, time.Month(d.Month), d.Day)-int(first)+7)%7; i > 0; i-- {
+		d.SubtractDay()
+	}
+	return d
+}
+
+// Position of d in a week starting on firstWeekday, 0 ... 6
+func weekdayColumn(year int, month int, day int) int {
+	return (Weekday(year, time.Month(month), day)-int(firstWeekday)+7)%7
+}
+
+// ISO 8601 week number of the week starting at start
+// Weeks that do not start on Monday are numbered by their Monday
+func isoWeekOf(start Date) int {
+	for i := 0; i < 7; i++ {
+		if Weekday(start.Year, time.Month(start.Month), start.Day) == int(time.Monday) { break }
+		start.AddDay()
+	}
+	_, week := time.Date(start.Year, time.Month(start.Month), start.Day, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
+
+// Abbreviated weekday name of the current locale, exactly 3 characters wide
+func weekdayAbbr(wd time.Weekday) string {
+	name := Weekday_abbr(int(wd))
+	if name == "" { name = wd.String() }
+	name = cutWidth(name, 3)
+	return name + strings.Repeat(" ", 3-stringWidth(name))
+}
+
+// Weekday names of a calendar row e.g. "Mon Tue Wed Thu Fri Sat Sun"
+func weekdayHeader() string {
+	names := []string{}
+	for i := 0; i < 7; i++ {
+		names = append(names, weekdayAbbr(time.Weekday((int(firstWeekday)+i)%7)))
+	}
+	return strings.Join(names, " ")
+}
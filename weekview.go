@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Draws the week of d as seven day columns against an hour grid
+// Timed events are drawn as blocks sized by their duration,
+// untimed events are listed in the all day strip above the grid
+// eventSelection is the index of the selected event of day d ( -1 for none )
+func drawWeek(
+	win *Window,
+	h int, w int, y int, x int, active bool,
+	d Date, events map[string][]Event, eventSelection int,
+	) {
+	labelWidth := hourLabelWidth() // "09:00 "
+
+	if active { Wattron(win, COLOR_PAIR(1)) }
+	drawBox(win, h, w, y, x)
+	Wattroff(win, COLOR_PAIR(1))
+
+	colWidth := (w - 2 - labelWidth) / 7
+	if colWidth < 3 || h < 8 { return }
+
+	days := [7]Date{}
+	dayEvents := [7][]Event{}
+	day := weekStart(d, firstWeekday)
+	for i := 0; i < 7; i++ {
+		days[i] = day
+		dayEvents[i] = events[day.NumericString()]
+		day.AddDay()
+	}
+	lanes, allDayRows := allDayLanes(dayEvents)
+	if allDayRows < 1 { allDayRows = 1 }
+	if allDayRows > 3 { allDayRows = 3 }
+
+	weekLabel := fmt.Sprintf(" %s %d - %s %d, %d ",
+		time.Month(days[0].Month).String()[:3], days[0].Day,
+		time.Month(days[6].Month).String()[:3], days[6].Day, days[6].Year)
+	Wattron(win, COLOR_PAIR(1))
+	Mvwprintw(win, y, x+2, trimMessage(weekLabel, w-4))
+	Wattroff(win, COLOR_PAIR(1))
+
+	// day header
+	row := y+1
+	for i, day := range days {
+		wd := weekdayAbbr(time.Weekday(Weekday(day.Year, time.Month(day.Month), day.Day)))
+		attrs := COLOR_PAIR(1)
+		if day == d { attrs |= A_BOLD }
+		Wattron(win, attrs)
+		Mvwprintw(win, row, x+1+labelWidth+i*colWidth, trimMessage(fmt.Sprintf("%s %d", wd, day.Day), colWidth-1))
+		Wattroff(win, attrs)
+	}
+	row++
+
+	// all day strip, spans are drawn as bars through the days they cover
+	for i := range days {
+		hidden := 0 // events in and below the last row if they do not fit
+		for _, lane := range lanes[i] {
+			if lane >= allDayRows-1 { hidden++ }
+		}
+		for ei, e := range dayEvents[i] {
+			lane, ok := lanes[i][ei]
+			if !ok || lane > allDayRows-1 { continue }
+			if lane == allDayRows-1 && hidden > 1 {
+				Mvwprintw(win, row+lane, x+1+labelWidth+i*colWidth, trimMessage(fmt.Sprintf("+%d more", hidden), colWidth-1))
+				continue
+			}
+			attrs := eventAttrs(e)
+			text := e.Title()
+			width := colWidth-1
+			if e.InSpan() {
+				attrs = COLOR_PAIR(6)
+				if e.SpanDay > 1 && i > 0 { text = "" } // title only where the bar starts
+				if e.SpanDay < e.SpanDays && i < 6 { width = colWidth }
+			}
+			if days[i] == d && ei == eventSelection {
+				attrs = COLOR_PAIR(1) | A_BOLD
+				if e.InSpan() { attrs = COLOR_PAIR(7) | A_BOLD }
+			}
+			Wattron(win, attrs)
+			Mvwprintw(win, row+lane, x+1+labelWidth+i*colWidth, padMessage(text, width))
+			Wattroff(win, attrs)
+		}
+	}
+	Mvwprintw(win, row, x+1, "all")
+	row += allDayRows
+	Mvwhline(win, row, x+1, ACS_HLINE, w-2)
+	row++
+
+	// hour grid, if there is not enough space for 24 hours only part of the day is shown
+	gridHeight := y+h-1-row
+	if gridHeight < 1 { return }
+	rowsPerHour := gridHeight / 24
+	if rowsPerHour < 1 { rowsPerHour = 1 }
+	visibleHours := gridHeight / rowsPerHour
+	startHour := weekGridStartHour(dayEvents, days, d, eventSelection, visibleHours)
+
+	for r := 0; r < gridHeight; r++ {
+		if r % rowsPerHour != 0 { continue }
+		hour := startHour + r/rowsPerHour
+		if hour > 23 { break }
+		Wattron(win, COLOR_PAIR(1))
+		Mvwprintw(win, row+r, x+1, formatHour(hour))
+		Wattroff(win, COLOR_PAIR(1))
+	}
+
+	for i := range days {
+		for ei, e := range dayEvents[i] {
+			if !e.IsTimed() { continue }
+			duration := e.EventDuration
+			if duration < 0 { duration = e.Duration }
+
+			top := (e.Time - startHour*60) * rowsPerHour / 60
+			height := 1
+			if duration > 0 { height = duration * rowsPerHour / 60 }
+			if height < 1 { height = 1 }
+
+			attrs := COLOR_PAIR(6)
+			if days[i] == d && ei == eventSelection { attrs = COLOR_PAIR(7) | A_BOLD }
+			Wattron(win, attrs)
+			for r := top; r < top+height; r++ {
+				if r < 0 { continue }
+				if r >= gridHeight { break }
+				text := ""
+				if r == top || (top < 0 && r == 0) { text = formatClock(e.Time) + " " + e.Title() }
+				Mvwprintw(win, row+r, x+1+labelWidth+i*colWidth, padMessage(text, colWidth-1))
+			}
+			Wattroff(win, attrs)
+		}
+	}
+}
+
+func countUntimed(events []Event) (count int) {
+	for _, e := range events {
+		if !e.IsTimed() { count++ }
+	}
+	return
+}
+
+// First hour shown in the grid, starts at 8:00 or earlier if there are
+// earlier events and always keeps the selected event visible
+func weekGridStartHour(dayEvents [7][]Event, days [7]Date, d Date, eventSelection int, visibleHours int) int {
+	if visibleHours >= 24 { return 0 }
+	startHour := 8
+	for _, evs := range dayEvents {
+		for _, e := range evs {
+			if e.IsTimed() && e.Time/60 < startHour { startHour = e.Time/60 }
+		}
+	}
+	for i, evs := range dayEvents {
+		if days[i] != d || eventSelection < 0 || eventSelection >= len(evs) { continue }
+		e := evs[eventSelection]
+		if !e.IsTimed() { continue }
+		if e.Time/60 < startHour { startHour = e.Time/60 }
+		if e.Time/60 >= startHour+visibleHours { startHour = e.Time/60 - visibleHours + 1 }
+	}
+	if startHour+visibleHours > 24 { startHour = 24 - visibleHours }
+	if startHour < 0 { startHour = 0 }
+	return startHour
+}
+
+// minutes after midnight as 24h clock e.g. 570 -> 09:30
+// used for the agenda and export formats, the ui uses formatClock
+func formatMinutes(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60%24, minutes%60)
+}
+
+// times in the ui are shown as 9:30am instead of 09:30 ( time_format = "12h" )
+var clock12h = false
+
+func parseTimeFormat(str string) (bool, error) {
+	switch strings.ToLower(str) {
+	case "24h", "24":
+		return false, nil
+	case "12h", "12":
+		return true, nil
+	}
+	return false, fmt.Errorf("expected 12h or 24h")
+}
+
+// minutes after midnight in the configured time format e.g. 09:30 or 9:30am
+func formatClock(minutes int) string {
+	if !clock12h { return formatMinutes(minutes) }
+	hour := minutes/60%24
+	suffix := "am"
+	if hour >= 12 { suffix = "pm" }
+	hour %= 12
+	if hour == 0 { hour = 12 }
+	return fmt.Sprintf("%d:%02d%s", hour, minutes%60, suffix)
+}
+
+// hour labels of the grids, 09:00 or 9am
+func formatHour(hour int) string {
+	if !clock12h { return fmt.Sprintf("%02d:00", hour) }
+	return strings.Replace(formatClock(hour*60), ":00", "", 1)
+}
+
+// Width of the hour labels of the grids including the space after them
+func hourLabelWidth() int {
+	return len(formatClock(0)) + 1
+}
+
+// "09:30–11:00" for events with a duration, "09:30" otherwise
+func formatTimeRange(e Event) string {
+	if !e.IsTimed() { return "" }
+	str := formatClock(e.Time)
+	if eventMinutes(e) > 0 { str += "–" + formatClock(e.Time+eventMinutes(e)) }
+	return str
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Display width of strings in terminal cells. Messages are measured in cells
+// instead of bytes so umlauts, CJK and emoji are neither cut in the middle of
+// a character nor misaligned
+
+// East Asian wide and fullwidth characters and emoji, they take two cells
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB},
+	{0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// Cells r takes up: 0 for combining and control characters, 2 for wide ones
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || (r >= 0x1160 && r <= 0x11FF):
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r < wide[0] { break }
+		if r <= wide[1] { return 2 }
+	}
+	return 1
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// Splits off the first character of str as the terminal draws it: a rune with its
+// combining marks and variation selectors, emoji joined with ZWJ or a flag made of
+// two regional indicators. Strings are only cut between these clusters
+func nextCluster(str string) (cluster string, width int, rest string) {
+	r, i := utf8.DecodeRuneInString(str)
+	width = runeWidth(r)
+	if isRegionalIndicator(r) {
+		if r2, size := utf8.DecodeRuneInString(str[i:]); isRegionalIndicator(r2) { i += size; width = 2 }
+	}
+	for i < len(str) {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		switch {
+		case r == 0x200D: // zero width joiner, the next character is drawn as part of this one
+			i += size
+			if i < len(str) { _, size = utf8.DecodeRuneInString(str[i:]); i += size }
+		case runeWidth(r) == 0 || (r >= 0x1F3FB && r <= 0x1F3FF): // combining marks and skin tones
+			i += size
+		default:
+			return str[:i], width, str[i:]
+		}
+	}
+	return str, width, ""
+}
+
+func stringWidth(str string) (width int) {
+	for str != "" {
+		_, w, rest := nextCluster(str)
+		width += w
+		str = rest
+	}
+	return
+}
+
+// Longest prefix of str that fits into max cells, combining characters stay
+// with the character before them
+func cutWidth(str string, max int) string {
+	width, rest := 0, str
+	for rest != "" {
+		_, w, next := nextCluster(rest)
+		if width+w > max { break }
+		width += w
+		rest = next
+	}
+	return str[:len(str)-len(rest)]
+}
+
+// Longest suffix of str that fits into max cells, like cutWidth it only cuts between clusters
+func cutWidthLeft(str string, max int) string {
+	starts := []int{}
+	for rest := str; rest != ""; {
+		starts = append(starts, len(str)-len(rest))
+		_, _, rest = nextCluster(rest)
+	}
+	width := 0
+	for i := len(starts)-1; i >= 0; i-- {
+		cluster, w, _ := nextCluster(str[starts[i]:])
+		if width+w > max { return str[starts[i]+len(cluster):] }
+		width += w
+	}
+	return str
+}
+
+// Trims str to max cells and fills it up with spaces to exactly max cells
+func padMessage(str string, max int) string {
+	str = trimMessage(str, max)
+	if w := stringWidth(str); w < max { str += strings.Repeat(" ", max-w) }
+	return str
+}
+
+// Splits str at spaces into lines of at most max cells, words wider than max are split
+func wrapWords(str string, max int) []string {
+	if max < 1 { return []string{str} }
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(str) {
+		switch {
+		case stringWidth(word) > max:
+			if line != "" { lines = append(lines, line) }
+			parts := wrapWidth(word, max)
+			lines = append(lines, parts[:len(parts)-1]...)
+			line = parts[len(parts)-1]
+		case line == "":
+			line = word
+		case stringWidth(line)+1+stringWidth(word) <= max:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 { lines = append(lines, line) }
+	return lines
+}
+
+// Splits str into lines of at most max cells
+func wrapWidth(str string, max int) []string {
+	if max < 1 { return []string{str} }
+	lines := []string{}
+	for {
+		line := cutWidth(str, max)
+		if line == "" { line, _, _ = nextCluster(str) } // wider than max, keep going
+		lines = append(lines, line)
+		str = str[len(line):]
+		if str == "" { return lines }
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r rune
+		want int
+	}{
+		{'a', 1},
+		{'ü', 1},
+		{'ß', 1},
+		{'–', 1},
+		{'日', 2},
+		{'そ', 2},
+		{'광', 2},
+		{'！', 2}, // fullwidth
+		{'😀', 2},
+		{'🍕', 2},
+		{'́', 0}, // combining acute
+		{'̈', 0}, // combining diaeresis
+		{'‍', 0}, // zero width joiner
+		{'️', 0}, // variation selector
+		{'\t', 0},
+		{'🇳', 1}, // regional indicator on its own
+	}
+	for _, test := range tests {
+		if got := runeWidth(test.r); got != test.want {
+			t.Errorf("runeWidth(%q) = %d, want %d", test.r, got, test.want)
+		}
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		str string
+		want int
+	}{
+		{"", 0},
+		{"Dentist", 7},
+		{"Müller, Königstraße", 19},
+		{"Zoë", 3},
+		{"été", 3},
+		{"大晦日", 6},
+		{"Ōmisoka 大晦日", 14},
+		{"🍕 Pizza", 8},
+		{"👨‍👩‍👧", 2}, // ZWJ family
+		{"👍🏽", 2}, // skin tone modifier
+		{"🇳🇴", 2}, // flag
+		{"🇳🇴🇦🇹", 4},
+		{"🇳", 1},
+	}
+	for _, test := range tests {
+		if got := stringWidth(test.str); got != test.want {
+			t.Errorf("stringWidth(%q) = %d, want %d", test.str, got, test.want)
+		}
+	}
+}
+
+func TestCutWidth(t *testing.T) {
+	tests := []struct {
+		str string
+		max int
+		want string
+	}{
+		{"abc", 0, ""},
+		{"abc", 5, "abc"},
+		{"Müller", 2, "Mü"},
+		{"大晦日", 3, "大"},
+		{"大晦日", 4, "大晦"},
+		{"Zoë et", 3, "Zoë"},
+		{"éx", 1, "é"},
+		{"🇳🇴🇦🇹", 3, "🇳🇴"},
+		{"👨‍👩‍👧x", 2, "👨‍👩‍👧"},
+		{"👨‍👩‍👧x", 1, ""},
+		{"👍🏽👍", 2, "👍🏽"},
+	}
+	for _, test := range tests {
+		if got := cutWidth(test.str, test.max); got != test.want {
+			t.Errorf("cutWidth(%q, %d) = %q, want %q", test.str, test.max, got, test.want)
+		}
+	}
+}
+
+func TestCutWidthLeft(t *testing.T) {
+	tests := []struct {
+		str string
+		max int
+		want string
+	}{
+		{"abc", 0, ""},
+		{"abc", 2, "bc"},
+		{"abc", 5, "abc"},
+		{"éx", 1, "x"},
+		{"éx", 2, "éx"},
+		{"xé", 1, "é"},
+		{"Königstraße", 4, "raße"},
+		{"年越しそば", 5, "そば"},
+		{"a🇳🇴", 2, "🇳🇴"},
+		{"a🇳🇴", 1, ""},
+		{"x👨‍👩‍👧", 2, "👨‍👩‍👧"},
+	}
+	for _, test := range tests {
+		if got := cutWidthLeft(test.str, test.max); got != test.want {
+			t.Errorf("cutWidthLeft(%q, %d) = %q, want %q", test.str, test.max, got, test.want)
+		}
+	}
+}
+
+func TestWrapWidth(t *testing.T) {
+	tests := []struct {
+		str string
+		max int
+		want []string
+	}{
+		{"", 4, []string{""}},
+		{"ab", 0, []string{"ab"}},
+		{"abcdef", 4, []string{"abcd", "ef"}},
+		{"年越しそば", 4, []string{"年越", "しそ", "ば"}},
+		{"日x", 1, []string{"日", "x"}}, // wider than max, keep going
+		{"NoëlNoël", 4, []string{"Noël", "Noël"}},
+		{"🇳🇴🇦🇹🇫🇮", 4, []string{"🇳🇴🇦🇹", "🇫🇮"}},
+	}
+	for _, test := range tests {
+		if got := wrapWidth(test.str, test.max); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrapWidth(%q, %d) = %q, want %q", test.str, test.max, got, test.want)
+		}
+	}
+}
+
+func TestWrapWords(t *testing.T) {
+	tests := []struct {
+		str string
+		max int
+		want []string
+	}{
+		{"", 10, []string{""}},
+		{"Renew passport", 0, []string{"Renew passport"}},
+		{"Renew passport", 20, []string{"Renew passport"}},
+		{"Zahnarzt Dr. Müller, Königstraße 12", 12, []string{"Zahnarzt Dr.", "Müller,", "Königstraße", "12"}},
+		{"大晦日 年越しそば", 6, []string{"大晦日", "年越し", "そば"}},
+		{"Heiligabend 🎄 Bescherung", 14, []string{"Heiligabend 🎄", "Bescherung"}},
+		{"Noël chez Zoë", 9, []string{"Noël chez", "Zoë"}},
+	}
+	for _, test := range tests {
+		if got := wrapWords(test.str, test.max); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrapWords(%q, %d) = %q, want %q", test.str, test.max, got, test.want)
+		}
+	}
+}
+
+func TestTrimMessage(t *testing.T) {
+	tests := []struct {
+		str string
+		max int
+		want string
+	}{
+		{"abc", 0, ""},
+		{"abc", 2, "."},
+		{"abc", 3, "abc"},
+		{"Frohes neues Jahr", 10, "Frohes ..."},
+		{"大晦日 年越しそば", 8, "大晦..."},
+		{"Noël", 4, "Noël"},
+		{"Noël!", 5, "Noël!"},
+		{"Zoë et Renée", 6, "Zoë..."},
+		{"🇳🇴 Syttende mai", 6, "🇳🇴 ..."},
+	}
+	for _, test := range tests {
+		if got := trimMessage(test.str, test.max); got != test.want {
+			t.Errorf("trimMessage(%q, %d) = %q, want %q", test.str, test.max, got, test.want)
+		}
+	}
+}
+
+func TestPadMessage(t *testing.T) {
+	tests := []struct {
+		str string
+		max int
+		want string
+	}{
+		{"", 3, "   "},
+		{"日本", 6, "日本  "},
+		{"日本語です", 5, "日..."},
+		{"日本語です", 6, "日... "}, // the next wide character does not fit, filled with a space
+		{"Zoë", 5, "Zoë  "},
+	}
+	for _, test := range tests {
+		got := padMessage(test.str, test.max)
+		if got != test.want {
+			t.Errorf("padMessage(%q, %d) = %q, want %q", test.str, test.max, got, test.want)
+		}
+		if stringWidth(got) != test.max {
+			t.Errorf("padMessage(%q, %d) is %d cells wide", test.str, test.max, stringWidth(got))
+		}
+	}
+}
+
+// Every message of the fixture trimmed, padded and wrapped to any width stays valid
+// UTF-8, fits and never starts with a combining character
+func TestUnicodeFixtureWidths(t *testing.T) {
+	check := func(name string, message string, max int, str string) {
+		if !utf8.ValidString(str) { t.Errorf("%s(%q, %d) = %q is not valid UTF-8", name, message, max, str) }
+		if stringWidth(str) > max && max > 0 { t.Errorf("%s(%q, %d) = %q is %d cells wide", name, message, max, str, stringWidth(str)) }
+		if r, _ := utf8.DecodeRuneInString(str); str != "" && runeWidth(r) == 0 {
+			t.Errorf("%s(%q, %d) = %q starts with a zero width character", name, message, max, str)
+		}
+	}
+	for _, r := range readUnicodeFixture(t) { // see remind_test.go
+		for max := 1; max <= stringWidth(r.message)+1; max++ {
+			check("trimMessage", r.message, max, trimMessage(r.message, max))
+			check("cutWidth", r.message, max, cutWidth(r.message, max))
+			check("cutWidthLeft", r.message, max, cutWidthLeft(r.message, max))
+			if got := padMessage(r.message, max); stringWidth(got) != max {
+				t.Errorf("padMessage(%q, %d) = %q is %d cells wide", r.message, max, got, stringWidth(got))
+			}
+			if max < 2 { continue } // wide characters do not fit
+			lines := wrapWords(r.message, max)
+			for _, line := range lines { check("wrapWords", r.message, max, line) }
+			if strings.Join(lines, " ") != strings.Join(strings.Fields(r.message), " ") && stringWidth(r.message) <= max {
+				t.Errorf("wrapWords(%q, %d) = %q lost text", r.message, max, lines)
+			}
+		}
+	}
+}
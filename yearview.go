@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Size of a mini month in the year view: name, weekdays and 6 weeks of 7 days
+const miniMonthHeight = 8
+const miniMonthWidth = 7*3 - 1
+
+// Draws the year of d as grid of mini months, days with events are colored like
+// in the calendar widget. If not all months fit the rows around d are shown
+func drawYear(
+	win *Window,
+	h int, w int, y int, x int,
+	d Date, today Date, events map[string][]Event, specials map[string]DaySpecial,
+	) {
+	Wattron(win, COLOR_PAIR(1))
+	drawBox(win, h, w, y, x)
+	yearLabel := " " + strconv.Itoa(d.Year) + " "
+	Mvwprintw(win, y, x+(w-stringWidth(yearLabel))/2, yearLabel)
+	Wattroff(win, COLOR_PAIR(1))
+
+	columns := 1
+	for _, c := range []int{6, 4, 3, 2} {
+		if c*(miniMonthWidth+2) <= w-2 { columns = c; break }
+	}
+	monthRows := 12 / columns
+	visibleRows := (h-2) / miniMonthHeight
+	if visibleRows < 1 { return }
+	if visibleRows > monthRows { visibleRows = monthRows }
+	firstRow := (d.Month-1)/columns - visibleRows + 1
+	if firstRow < 0 { firstRow = 0 }
+
+	left := x + (w - columns*(miniMonthWidth+2))/2 + 1
+	top := y + 1 + (h-2 - visibleRows*miniMonthHeight)/2
+	for row := 0; row < visibleRows; row++ {
+		for col := 0; col < columns; col++ {
+			month := (firstRow+row)*columns + col + 1
+			drawMiniMonth(win, top+row*miniMonthHeight, left+col*(miniMonthWidth+2), d.Year, month, d, today, events, specials)
+		}
+	}
+}
+
+func drawMiniMonth(
+	win *Window, y int, x int, year int, month int,
+	d Date, today Date, events map[string][]Event, specials map[string]DaySpecial,
+	) {
+	name := time.Month(month).String()
+	attrs := COLOR_PAIR(1)
+	if month == d.Month { attrs |= A_BOLD }
+	Wattron(win, attrs)
+	Mvwprintw(win, y, x+(miniMonthWidth-stringWidth(name))/2, name)
+	Wattroff(win, attrs)
+
+	names := []string{}
+	for i := 0; i < 7; i++ {
+		abbr := cutWidth(weekdayAbbr(time.Weekday((int(firstWeekday)+i)%7)), 2)
+		names = append(names, abbr + strings.Repeat(" ", 2-stringWidth(abbr)))
+	}
+	Wattron(win, COLOR_PAIR(1))
+	Mvwprintw(win, y+1, x, strings.Join(names, " "))
+	Wattroff(win, COLOR_PAIR(1))
+
+	col := weekdayColumn(year, month, 1)
+	row := 0
+	for day := 1; day <= DaysInMonth(year, time.Month(month)); day++ {
+		key := NumericString(year, month, day)
+		attrs, fg := 0, -1
+		if _, ok := events[key]; ok { attrs, fg = COLOR_PAIR(2), eventsFg }
+		if year == today.Year && month == today.Month && day == today.Day { attrs, fg = COLOR_PAIR(3), todayFg }
+		if special, ok := specials[key]; ok {
+			if shade := shadeAttrs(special, fg); shade != 0 { attrs = shade }
+		}
+		if year == d.Year && month == d.Month && day == d.Day { attrs = COLOR_PAIR(1) | A_REVERSE | A_BOLD }
+
+		Wattron(win, attrs)
+		Mvwprintw(win, y+2+row, x+col*3, fmt.Sprintf("%2d", day))
+		Wattroff(win, attrs)
+
+		col++
+		if col == 7 { col = 0; row++ }
+	}
+}